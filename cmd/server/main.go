@@ -14,10 +14,13 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"prova-fattocs/internal/app"
 	"prova-fattocs/internal/config"
 	"prova-fattocs/internal/infra/database"
+	"prova-fattocs/internal/infra/repository"
 	"prova-fattocs/internal/routes"
 
 	cors "github.com/gin-contrib/cors"
@@ -42,12 +45,35 @@ func main() {
 
 	cfg := config.Load()
 	db := database.NewPostgresConnection(cfg)
+	broker := app.NewBroker(cfg.EventBufferSize)
+	// Edit locks are held in this process only; see app.Collaboration.
+	collab := app.NewCollaboration(cfg.LockTTL)
+	taskService := app.NewTaskService(repository.NewPostgresTaskRepository(db)).WithBroker(broker).WithCollaboration(collab)
+	auditService := app.NewAuditService(repository.NewPostgresTaskEventRepository(db))
+	webhookService := app.NewWebhookService(repository.NewPostgresWebhookRepository(db), app.WebhookOptions{
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		Backoff:     cfg.WebhookBackoff,
+		MaxBackoff:  cfg.WebhookMaxBackoff,
+		Timeout:     cfg.WebhookTimeout,
+
+		AllowedNetworks: cfg.WebhookAllowedNetworks,
+	})
+	go app.RunTrashPurger(context.Background(), taskService, cfg.TrashRetention, cfg.TrashPurgeInterval)
+	outboxRepo := repository.NewPostgresOutboxRepository(db)
+	outbox := app.NewOutboxDispatcher(outboxRepo, webhookService.Enqueue)
+	go app.RunOutboxFeed(context.Background(), app.NewOutboxFeed(outboxRepo, broker, cfg.EventBufferSize), cfg.OutboxPollInterval)
+	go app.RunOutboxDispatcher(context.Background(), outbox, cfg.OutboxPollInterval)
+	go app.RunOutboxPurger(context.Background(), outbox, cfg.OutboxRetention, cfg.OutboxPurgeInterval)
+	go app.RunWebhookDispatcher(context.Background(), webhookService, cfg.WebhookPollInterval)
+
 	r := gin.Default()
+	r.Use(routes.RequestID())
 
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-Match", "If-None-Match", "If-Modified-Since", "Last-Event-ID", "X-Actor", "X-Lock-Token", "X-Request-ID"}
+	corsConfig.ExposeHeaders = []string{"ETag", "Last-Modified", "X-Request-ID"}
 	r.Use(cors.New(corsConfig))
 
 	r.ForwardedByClientIP = false
@@ -61,7 +87,10 @@ func main() {
 	// @Router /swagger/*any [get]
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	routes.SetupRoutes(r, db)
+	routes.SetupRoutes(r, taskService)
+	routes.SetupAuditRoutes(r, auditService)
+	routes.SetupCollaborationRoutes(r, taskService, collab)
+	routes.SetupWebhookRoutes(r, webhookService)
 
 	log.Fatal(r.Run(":" + cfg.ServerPort))
 }
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+)
+
+// AuditService reads the audit log of task changes.
+type AuditService struct {
+	repo repository.TaskEventRepository
+}
+
+func NewAuditService(repo repository.TaskEventRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// List returns the page of events selected by filter, newest first. A limit
+// of 0 uses domain.DefaultAuditLimit.
+func (s *AuditService) List(filter domain.AuditFilter) ([]domain.TaskEvent, domain.Pagination, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, domain.Pagination{}, err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = domain.DefaultAuditLimit
+	}
+
+	events, total, err := s.repo.List(filter)
+	if err != nil {
+		return nil, domain.Pagination{}, err
+	}
+	return events, filter.Pagination(total), nil
+}
+
+// History lists the events of a single task, including after it was purged.
+func (s *AuditService) History(taskID int64, filter domain.AuditFilter) ([]domain.TaskEvent, domain.Pagination, error) {
+	filter.TaskID = taskID
+	return s.List(filter)
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"errors"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/mocks"
+	"testing"
+	"time"
+)
+
+func TestListAuditEvents_DefaultLimit(t *testing.T) {
+	mockRepo := &mocks.TaskEventRepositoryMock{
+		ListFunc: func(filter domain.AuditFilter) ([]domain.TaskEvent, int, error) {
+			if filter.Limit != domain.DefaultAuditLimit {
+				t.Errorf("expected default limit %d but got %d", domain.DefaultAuditLimit, filter.Limit)
+			}
+			return []domain.TaskEvent{{ID: 1, TaskID: 1, Action: domain.ActionCreated}}, 250, nil
+		},
+	}
+
+	service := NewAuditService(mockRepo)
+
+	events, pagination, err := service.List(domain.AuditFilter{})
+	if err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("expected 1 event but got %d", len(events))
+	}
+	if pagination.TotalPages != 3 {
+		t.Errorf("expected 3 pages but got %d", pagination.TotalPages)
+	}
+}
+
+func TestListAuditEvents_InvalidRange(t *testing.T) {
+	mockRepo := &mocks.TaskEventRepositoryMock{}
+
+	service := NewAuditService(mockRepo)
+
+	from := time.Date(2025, time.August, 10, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+	_, _, err := service.List(domain.AuditFilter{From: &from, To: &to})
+
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected validation error but got %v", err)
+	}
+	if validationErr.Fields[0].Field != "from" || validationErr.Fields[0].Code != "invalid_range" {
+		t.Errorf("expected from invalid_range but got %+v", validationErr.Fields)
+	}
+}
+
+func TestTaskHistory(t *testing.T) {
+	mockRepo := &mocks.TaskEventRepositoryMock{
+		ListFunc: func(filter domain.AuditFilter) ([]domain.TaskEvent, int, error) {
+			if filter.TaskID != 7 || filter.Actor != "ana" {
+				t.Errorf("expected events of task 7 by ana but got %+v", filter)
+			}
+			return nil, 0, nil
+		},
+	}
+
+	service := NewAuditService(mockRepo)
+
+	if _, _, err := service.History(7, domain.AuditFilter{Actor: "ana"}); err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"prova-fattocs/internal/domain"
+	"sync"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is dropped. A dropped client reconnects and resumes from the broker's
+// buffer.
+const subscriberBuffer = 64
+
+// Broker fans committed task changes out to live subscribers and keeps the
+// latest ones so clients can resume after reconnecting.
+//
+// Event IDs are outbox ids, given by the OutboxFeed that publishes to the
+// broker, so they are the same on every instance and across restarts.
+type Broker struct {
+	mu          sync.Mutex
+	floor       uint64 // every event after floor up to last is buffered
+	last        uint64
+	buffer      []domain.ChangeEvent
+	size        int
+	subscribers map[chan domain.ChangeEvent]struct{}
+}
+
+// NewBroker returns a broker remembering the last size events.
+func NewBroker(size int) *Broker {
+	return &Broker{
+		size:        size,
+		subscribers: make(map[chan domain.ChangeEvent]struct{}),
+	}
+}
+
+// Reset forgets the buffered events and continues after position: clients
+// resuming from an earlier ID are told to reload.
+func (b *Broker) Reset(position uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.floor, b.last, b.buffer = position, position, nil
+}
+
+// Publish remembers the event and sends it to every subscriber. Its ID must
+// be greater than that of every event published before; events that are
+// not are dropped as duplicates.
+func (b *Broker) Publish(event domain.ChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.ID <= b.last {
+		return
+	}
+	b.last = event.ID
+
+	if b.size > 0 {
+		if len(b.buffer) == b.size {
+			b.floor = b.buffer[0].ID
+			copy(b.buffer, b.buffer[1:])
+			b.buffer = b.buffer[:b.size-1]
+		}
+		b.buffer = append(b.buffer, event)
+	} else {
+		b.floor = event.ID
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe registers a subscriber. When resuming after lastID it also
+// returns the buffered events published since; complete is false if some of
+// them are no longer buffered, in which case the client has to reload. The
+// channel is closed if the subscriber falls too far behind; cancel must be
+// called once the subscriber is done.
+func (b *Broker) Subscribe(lastID uint64, resume bool) (missed []domain.ChangeEvent, events <-chan domain.ChangeEvent, complete bool, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if resume {
+		complete = lastID >= b.floor && lastID <= b.last
+		for _, event := range b.buffer {
+			if complete && event.ID > lastID {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	ch := make(chan domain.ChangeEvent, subscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return missed, ch, complete, cancel
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"prova-fattocs/internal/domain"
+	"testing"
+)
+
+func change(id uint64) domain.ChangeEvent {
+	return domain.ChangeEvent{ID: id, Type: domain.ChangeDeleted, TaskID: int64(id)}
+}
+
+func TestBroker_PublishSubscribe(t *testing.T) {
+	broker := NewBroker(10)
+	_, events, _, cancel := broker.Subscribe(0, false)
+	defer cancel()
+
+	broker.Publish(change(3))
+	broker.Publish(change(3))
+	broker.Publish(change(5))
+
+	if got := <-events; got.ID != 3 || got.Type != domain.ChangeDeleted || got.TaskID != 3 {
+		t.Errorf("expected the published event but got %+v", got)
+	}
+	if got := <-events; got.ID != 5 {
+		t.Errorf("expected the duplicate to be dropped but got %+v", got)
+	}
+}
+
+func TestBroker_Resume(t *testing.T) {
+	broker := NewBroker(2)
+	broker.Publish(change(1))
+	// IDs may skip values left by rolled back transactions.
+	broker.Publish(change(4))
+	broker.Publish(change(5))
+
+	missed, _, complete, cancel := broker.Subscribe(4, true)
+	cancel()
+	if !complete || len(missed) != 1 || missed[0].ID != 5 {
+		t.Errorf("expected only the third event but got %+v (complete %v)", missed, complete)
+	}
+
+	missed, _, complete, cancel = broker.Subscribe(5, true)
+	cancel()
+	if !complete || len(missed) != 0 {
+		t.Errorf("expected nothing missed but got %+v (complete %v)", missed, complete)
+	}
+
+	// Everything after the first event is still buffered.
+	missed, _, complete, cancel = broker.Subscribe(1, true)
+	cancel()
+	if !complete || len(missed) != 2 {
+		t.Errorf("expected the second and third events but got %+v (complete %v)", missed, complete)
+	}
+
+	// The first event fell out of the buffer, so it cannot be replayed.
+	missed, _, complete, cancel = broker.Subscribe(0, true)
+	cancel()
+	if complete || len(missed) != 0 {
+		t.Errorf("expected an incomplete resume but got %+v (complete %v)", missed, complete)
+	}
+
+	// IDs the broker has not reached are never resumable.
+	missed, _, complete, cancel = NewBroker(2).Subscribe(6, true)
+	cancel()
+	if complete {
+		t.Errorf("expected an unknown ID to be incomplete but got %+v", missed)
+	}
+}
+
+func TestBroker_Reset(t *testing.T) {
+	broker := NewBroker(10)
+	broker.Reset(100)
+	broker.Publish(change(101))
+
+	missed, _, complete, cancel := broker.Subscribe(100, true)
+	cancel()
+	if !complete || len(missed) != 1 {
+		t.Errorf("expected to resume from the reset position but got %+v (complete %v)", missed, complete)
+	}
+
+	_, _, complete, cancel = broker.Subscribe(99, true)
+	cancel()
+	if complete {
+		t.Error("expected IDs before the reset position not to be resumable")
+	}
+}
+
+func TestBroker_DropsSlowSubscribers(t *testing.T) {
+	broker := NewBroker(0)
+	_, events, _, cancel := broker.Subscribe(0, false)
+	defer cancel()
+
+	for i := 1; i <= subscriberBuffer+1; i++ {
+		broker.Publish(change(uint64(i)))
+	}
+
+	received := 0
+	for range events {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("expected %d events before the channel closed but got %d", subscriberBuffer, received)
+	}
+}
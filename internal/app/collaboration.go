@@ -0,0 +1,230 @@
+package app
+
+import (
+	"crypto/rand"
+	"errors"
+	"prova-fattocs/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Collaboration tracks who is connected to the collaboration channel and the
+// soft locks they hold on tasks. A lock belongs to the session that took it,
+// not to the user name, which is not authenticated: writers prove they hold
+// it by presenting the session's token. Locks expire after the hub's TTL
+// unless the holder renews them, and are released when the session that took
+// them leaves. Every change to presence is broadcast to all sessions.
+//
+// Sessions and locks live in the memory of one process: unlike the outbox,
+// they are not shared between instances, so the server has to run as a
+// single instance while the collaboration channel is in use.
+type Collaboration struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[*Session]struct{}
+	locks    map[int64]*heldLock
+}
+
+// errSessionLeft is returned when a session that has left tries to lock.
+var errSessionLeft = errors.New("session has left the collaboration channel")
+
+type heldLock struct {
+	lock    domain.TaskLock
+	session *Session
+	timer   *time.Timer
+}
+
+// Session is one connection to the collaboration channel. Token is secret to
+// the session's client and is returned with every lock it takes.
+type Session struct {
+	User  string
+	Token string
+
+	hub  *Collaboration
+	send chan domain.CollabMessage
+	left bool
+}
+
+// NewCollaboration returns a hub whose locks last ttl.
+func NewCollaboration(ttl time.Duration) *Collaboration {
+	return &Collaboration{
+		ttl:      ttl,
+		sessions: make(map[*Session]struct{}),
+		locks:    make(map[int64]*heldLock),
+	}
+}
+
+// Join connects a session for user and announces it. The session's messages
+// start with the current presence.
+func (c *Collaboration) Join(user string) *Session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &Session{User: user, Token: rand.Text(), hub: c, send: make(chan domain.CollabMessage, subscriberBuffer)}
+	c.sessions[s] = struct{}{}
+	c.broadcastPresence()
+	return s
+}
+
+// CheckLock returns a *domain.LockedError if the task is locked by a session
+// other than the one token belongs to.
+func (c *Collaboration) CheckLock(taskID int64, token string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if held, ok := c.locks[taskID]; ok && held.session.Token != token {
+		return &domain.LockedError{Lock: held.lock}
+	}
+	return nil
+}
+
+// Presence returns the connected users, sorted by name.
+func (c *Collaboration) Presence() []domain.PresenceUser {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.presence()
+}
+
+func (c *Collaboration) presence() []domain.PresenceUser {
+	users := make(map[string]*domain.PresenceUser)
+	for s := range c.sessions {
+		if users[s.User] == nil {
+			users[s.User] = &domain.PresenceUser{User: s.User, Editing: []int64{}}
+		}
+		users[s.User].Sessions++
+	}
+	for id, held := range c.locks {
+		if u := users[held.lock.User]; u != nil {
+			u.Editing = append(u.Editing, id)
+		}
+	}
+
+	presence := make([]domain.PresenceUser, 0, len(users))
+	for _, u := range users {
+		sort.Slice(u.Editing, func(i, j int) bool { return u.Editing[i] < u.Editing[j] })
+		presence = append(presence, *u)
+	}
+	sort.Slice(presence, func(i, j int) bool { return presence[i].User < presence[j].User })
+	return presence
+}
+
+func (c *Collaboration) broadcastPresence() {
+	msg := domain.CollabMessage{Type: domain.CollabPresence, Users: c.presence()}
+	for s := range c.sessions {
+		c.deliver(s, msg)
+	}
+}
+
+// deliver sends msg to s, disconnecting it if it has fallen too far behind.
+func (c *Collaboration) deliver(s *Session, msg domain.CollabMessage) {
+	select {
+	case s.send <- msg:
+	default:
+		c.leave(s)
+	}
+}
+
+func (c *Collaboration) leave(s *Session) {
+	if s.left {
+		return
+	}
+	s.left = true
+	delete(c.sessions, s)
+	close(s.send)
+	for id, held := range c.locks {
+		if held.session == s {
+			held.timer.Stop()
+			delete(c.locks, id)
+		}
+	}
+}
+
+func (c *Collaboration) expire(id int64, held *heldLock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.locks[id] != held {
+		// Renewed or released in the meantime.
+		return
+	}
+	delete(c.locks, id)
+	if !held.session.left {
+		c.deliver(held.session, domain.CollabMessage{Type: domain.CollabUnlocked, TaskID: id, Message: "lock expired"})
+	}
+	c.broadcastPresence()
+}
+
+// Messages returns the messages to send to the session's client. The
+// channel is closed when the session leaves.
+func (s *Session) Messages() <-chan domain.CollabMessage {
+	return s.send
+}
+
+// Reply queues msg for the session's client only.
+func (s *Session) Reply(msg domain.CollabMessage) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if !s.left {
+		s.hub.deliver(s, msg)
+	}
+}
+
+// Lock takes or renews the lock on a task for the session. It returns a
+// *domain.LockedError if another session holds it, even one of the same
+// user. The returned lock carries the session's token.
+func (s *Session) Lock(taskID int64) (domain.TaskLock, error) {
+	c := s.hub
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s.left {
+		return domain.TaskLock{}, errSessionLeft
+	}
+	if held, ok := c.locks[taskID]; ok {
+		if held.session != s {
+			return domain.TaskLock{}, &domain.LockedError{Lock: held.lock}
+		}
+		held.timer.Stop()
+	}
+
+	held := &heldLock{
+		lock:    domain.TaskLock{TaskID: taskID, User: s.User, ExpiresAt: time.Now().Add(c.ttl).UTC()},
+		session: s,
+	}
+	held.timer = time.AfterFunc(c.ttl, func() { c.expire(taskID, held) })
+	c.locks[taskID] = held
+	c.broadcastPresence()
+
+	lock := held.lock
+	lock.Token = s.Token
+	return lock, nil
+}
+
+// Unlock releases the lock the session holds on a task, if any.
+func (s *Session) Unlock(taskID int64) {
+	c := s.hub
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if held, ok := c.locks[taskID]; ok && held.session == s {
+		held.timer.Stop()
+		delete(c.locks, taskID)
+		c.broadcastPresence()
+	}
+}
+
+// Leave disconnects the session, releasing its locks.
+func (s *Session) Leave() {
+	c := s.hub
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !s.left {
+		c.leave(s)
+		c.broadcastPresence()
+	}
+}
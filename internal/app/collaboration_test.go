@@ -0,0 +1,271 @@
+package app
+
+import (
+	"errors"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+	"prova-fattocs/internal/mocks"
+	"testing"
+	"time"
+)
+
+// lastPresence drains the session's pending messages and returns the last
+// presence among them.
+func lastPresence(t *testing.T, s *Session) []domain.PresenceUser {
+	t.Helper()
+	var presence []domain.PresenceUser
+	for {
+		select {
+		case msg := <-s.Messages():
+			if msg.Type == domain.CollabPresence {
+				presence = msg.Users
+			}
+		default:
+			return presence
+		}
+	}
+}
+
+func TestCollaboration_PresenceAndLocks(t *testing.T) {
+	collab := NewCollaboration(time.Minute)
+	ana := collab.Join("ana")
+	bruno := collab.Join("bruno")
+
+	if _, err := ana.Lock(7); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+
+	presence := lastPresence(t, bruno)
+	if len(presence) != 2 || presence[0].User != "ana" || len(presence[0].Editing) != 1 || presence[0].Editing[0] != 7 {
+		t.Errorf("expected ana editing task 7 but got %+v", presence)
+	}
+
+	_, err := bruno.Lock(7)
+	var lockedErr *domain.LockedError
+	if !errors.As(err, &lockedErr) || lockedErr.Lock.User != "ana" {
+		t.Errorf("expected the task to be locked by ana but got %v", err)
+	}
+	if err := collab.CheckLock(7, bruno.Token); !errors.Is(err, domain.ErrLocked) {
+		t.Errorf("expected ErrLocked for bruno but got %v", err)
+	}
+	if err := collab.CheckLock(7, ""); !errors.Is(err, domain.ErrLocked) {
+		t.Errorf("expected ErrLocked without a token but got %v", err)
+	}
+	if err := collab.CheckLock(7, ana.Token); err != nil {
+		t.Errorf("expected the holder to pass but got %v", err)
+	}
+
+	// Leaving releases the session's locks.
+	ana.Leave()
+	if err := collab.CheckLock(7, bruno.Token); err != nil {
+		t.Errorf("expected the lock to be released but got %v", err)
+	}
+	presence = lastPresence(t, bruno)
+	if len(presence) != 1 || presence[0].User != "bruno" {
+		t.Errorf("expected only bruno to be present but got %+v", presence)
+	}
+	for range ana.Messages() {
+		// Drained until closed by Leave.
+	}
+}
+
+func TestCollaboration_LocksBelongToSessions(t *testing.T) {
+	collab := NewCollaboration(time.Minute)
+	first := collab.Join("anonymous")
+	defer first.Leave()
+	second := collab.Join("anonymous")
+	defer second.Leave()
+
+	lock, err := first.Lock(7)
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if lock.Token != first.Token || lock.Token == second.Token {
+		t.Errorf("expected the lock to carry the first session's token but got %+v", lock)
+	}
+
+	_, err = second.Lock(7)
+	var lockedErr *domain.LockedError
+	if !errors.As(err, &lockedErr) || lockedErr.Lock.Token != "" {
+		t.Errorf("expected a locked error without the token but got %v", err)
+	}
+	if err := collab.CheckLock(7, second.Token); !errors.Is(err, domain.ErrLocked) {
+		t.Errorf("expected ErrLocked for another session of the same user but got %v", err)
+	}
+	second.Unlock(7)
+	if err := collab.CheckLock(7, ""); !errors.Is(err, domain.ErrLocked) {
+		t.Errorf("expected another session not to release the lock but got %v", err)
+	}
+}
+
+func TestCollaboration_LocksExpire(t *testing.T) {
+	collab := NewCollaboration(20 * time.Millisecond)
+	ana := collab.Join("ana")
+	defer ana.Leave()
+
+	if _, err := ana.Lock(7); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case msg := <-ana.Messages():
+			if msg.Type != domain.CollabUnlocked {
+				continue
+			}
+			if msg.TaskID != 7 {
+				t.Errorf("expected task 7 to be unlocked but got %+v", msg)
+			}
+			if err := collab.CheckLock(7, ""); err != nil {
+				t.Errorf("expected the lock to have expired but got %v", err)
+			}
+			return
+		case <-timeout:
+			t.Fatal("expected the lock to expire")
+		}
+	}
+}
+
+func TestTaskService_UpdateLockedTask(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ExistsByNameFunc: func(name string, id int64) (bool, error) { return false, nil },
+		UpdateFunc: func(id int64, task domain.Task) (domain.Task, error) {
+			task.ID = id
+			return task, nil
+		},
+	}
+	mockRepo.WithAuditFunc = func(info domain.AuditInfo) repository.TaskRepository { return mockRepo }
+
+	collab := NewCollaboration(time.Minute)
+	ana := collab.Join("ana")
+	defer ana.Leave()
+	if _, err := ana.Lock(7); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+
+	service := NewTaskService(mockRepo).WithCollaboration(collab).WithAudit(domain.AuditInfo{Actor: "ana"})
+	task := domain.Task{Name: "Editada", Cost: 100, Deadline: domain.NewDate(2030, 1, 1)}
+
+	// The user name alone does not prove the lock is held.
+	_, err := service.Update(7, task)
+	if !errors.Is(err, domain.ErrLocked) {
+		t.Errorf("expected ErrLocked but got %v", err)
+	}
+	if _, err := service.WithLockToken(ana.Token).Update(7, task); err != nil {
+		t.Errorf("expected the lock holder to update but got %v", err)
+	}
+	if _, err := service.Update(8, task); err != nil {
+		t.Errorf("expected an unlocked task to update but got %v", err)
+	}
+}
+
+// lockTask returns a hub in which a session holds the lock on task 7.
+func lockTask(t *testing.T) (*Collaboration, *Session) {
+	t.Helper()
+	collab := NewCollaboration(time.Minute)
+	ana := collab.Join("ana")
+	t.Cleanup(ana.Leave)
+	if _, err := ana.Lock(7); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	return collab, ana
+}
+
+func TestTaskService_TransitionLockedTask(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		GetByIDFunc: func(id int64) (domain.Task, error) {
+			return domain.Task{ID: id, Status: domain.StatusTodo}, nil
+		},
+		UpdateStatusFunc: func(id int64, from, to domain.Status, version int64) (domain.Task, error) {
+			return domain.Task{ID: id, Status: to}, nil
+		},
+	}
+	collab, ana := lockTask(t)
+	service := NewTaskService(mockRepo).WithCollaboration(collab)
+
+	if _, err := service.Transition(7, domain.StatusInProgress, 0); !errors.Is(err, domain.ErrLocked) {
+		t.Errorf("expected ErrLocked but got %v", err)
+	}
+	if _, err := service.WithLockToken(ana.Token).Transition(7, domain.StatusInProgress, 0); err != nil {
+		t.Errorf("expected the lock holder to change the status but got %v", err)
+	}
+}
+
+func TestTaskService_DeleteLockedTask(t *testing.T) {
+	deleted := 0
+	mockRepo := &mocks.TaskRepositoryMock{
+		DeleteFunc: func(id int64, version int64) error {
+			deleted++
+			return nil
+		},
+	}
+	collab, ana := lockTask(t)
+	service := NewTaskService(mockRepo).WithCollaboration(collab)
+
+	if err := service.Delete(7, 0); !errors.Is(err, domain.ErrLocked) {
+		t.Errorf("expected ErrLocked but got %v", err)
+	}
+	if deleted != 0 {
+		t.Error("expected the locked task not to be deleted")
+	}
+	if err := service.WithLockToken(ana.Token).Delete(7, 0); err != nil {
+		t.Errorf("expected the lock holder to delete but got %v", err)
+	}
+}
+
+func TestTaskService_RestoreLockedTask(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		RestoreFunc: func(id int64, version int64) (domain.Task, error) {
+			return domain.Task{ID: id}, nil
+		},
+	}
+	collab, ana := lockTask(t)
+	service := NewTaskService(mockRepo).WithCollaboration(collab)
+
+	if _, err := service.Restore(7, 0); !errors.Is(err, domain.ErrLocked) {
+		t.Errorf("expected ErrLocked but got %v", err)
+	}
+	if _, err := service.WithLockToken(ana.Token).Restore(7, 0); err != nil {
+		t.Errorf("expected the lock holder to restore but got %v", err)
+	}
+}
+
+func TestTaskService_RevertLockedTask(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		GetRevisionFunc: func(id int64, revision int) (domain.TaskRevision, error) {
+			return domain.TaskRevision{TaskID: id, Revision: revision, Name: "Antiga"}, nil
+		},
+		ExistsByNameFunc: func(name string, id int64) (bool, error) { return false, nil },
+		RevertFunc: func(id int64, revision int, version int64) (domain.Task, error) {
+			return domain.Task{ID: id, Name: "Antiga"}, nil
+		},
+	}
+	collab, ana := lockTask(t)
+	service := NewTaskService(mockRepo).WithCollaboration(collab)
+
+	if _, err := service.Revert(7, 1, 0); !errors.Is(err, domain.ErrLocked) {
+		t.Errorf("expected ErrLocked but got %v", err)
+	}
+	if _, err := service.WithLockToken(ana.Token).Revert(7, 1, 0); err != nil {
+		t.Errorf("expected the lock holder to revert but got %v", err)
+	}
+}
+
+func TestTaskService_ReorderLockedTask(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ReorderFunc: func(id, position, version int64) error { return nil },
+	}
+	collab, ana := lockTask(t)
+	service := NewTaskService(mockRepo).WithCollaboration(collab)
+
+	if err := service.Reorder(7, 1, 0); !errors.Is(err, domain.ErrLocked) {
+		t.Errorf("expected ErrLocked but got %v", err)
+	}
+	if err := service.WithLockToken(ana.Token).Reorder(7, 1, 0); err != nil {
+		t.Errorf("expected the lock holder to reorder but got %v", err)
+	}
+	if err := service.Reorder(8, 1, 0); err != nil {
+		t.Errorf("expected an unlocked task to reorder but got %v", err)
+	}
+}
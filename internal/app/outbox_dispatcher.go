@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+	"time"
+)
+
+// outboxBatch is how many outbox events are dispatched per transaction.
+const outboxBatch = 100
+
+// OutboxHandler consumes events from the outbox. An event may be handed to
+// it more than once, so it must discard duplicates by Change.EventID or be
+// harmless to repeat.
+type OutboxHandler func(domain.OutboxEvent) error
+
+// OutboxDispatcher publishes committed changes from the outbox to its
+// handlers, in commit order and at least once. Only one process dispatches at
+// a time, so handlers are for work that must happen once, such as queueing
+// webhooks; live clients are served by each process's OutboxFeed. A change is
+// only marked published once every handler accepted it; if one fails, the
+// change and those after it are retried on the next run.
+type OutboxDispatcher struct {
+	repo     repository.OutboxRepository
+	handlers []OutboxHandler
+}
+
+func NewOutboxDispatcher(repo repository.OutboxRepository, handlers ...OutboxHandler) *OutboxDispatcher {
+	return &OutboxDispatcher{repo: repo, handlers: handlers}
+}
+
+// DispatchPending publishes every unpublished change and returns how many
+// were published.
+func (d *OutboxDispatcher) DispatchPending() (int, error) {
+	total := 0
+	for {
+		n, err := d.repo.Dispatch(outboxBatch, d.publish)
+		total += n
+		if err != nil || n < outboxBatch {
+			return total, err
+		}
+	}
+}
+
+func (d *OutboxDispatcher) publish(event domain.OutboxEvent) error {
+	for _, handle := range d.handlers {
+		if err := handle(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgePublished deletes the changes published longer than retention ago.
+func (d *OutboxDispatcher) PurgePublished(retention time.Duration) (int64, error) {
+	return d.repo.PurgePublished(time.Now().Add(-retention))
+}
+
+// RunOutboxDispatcher publishes outbox changes every interval until ctx is
+// cancelled.
+func RunOutboxDispatcher(ctx context.Context, d *OutboxDispatcher, interval time.Duration) {
+	slog.Info("Starting outbox dispatcher", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchPending(); err != nil {
+			slog.Error("Failed to dispatch outbox", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping outbox dispatcher")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOutboxPurger deletes published changes older than retention every
+// interval until ctx is cancelled.
+func RunOutboxPurger(ctx context.Context, d *OutboxDispatcher, retention, interval time.Duration) {
+	if retention <= 0 || interval <= 0 {
+		slog.Info("Outbox purge disabled")
+		return
+	}
+
+	slog.Info("Starting outbox purger", "retention", retention, "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.PurgePublished(retention); err != nil {
+			slog.Error("Failed to purge outbox", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping outbox purger")
+			return
+		case <-ticker.C:
+		}
+	}
+}
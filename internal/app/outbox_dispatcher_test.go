@@ -0,0 +1,141 @@
+package app
+
+import (
+	"errors"
+	"prova-fattocs/internal/domain"
+	"testing"
+	"time"
+)
+
+// outboxMock keeps the outbox in memory and behaves like
+// PostgresOutboxRepository. The events before published are published.
+type outboxMock struct {
+	events    []domain.OutboxEvent
+	published int
+}
+
+func (m *outboxMock) After(id int64, limit int) ([]domain.OutboxEvent, error) {
+	var events []domain.OutboxEvent
+	for _, e := range m.events {
+		if e.ID > id && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *outboxMock) LastID() (int64, error) {
+	if len(m.events) == 0 {
+		return 0, nil
+	}
+	return m.events[len(m.events)-1].ID, nil
+}
+
+func (m *outboxMock) Dispatch(limit int, publish func(domain.OutboxEvent) error) (int, error) {
+	n := 0
+	for m.published < len(m.events) && n < limit {
+		if err := publish(m.events[m.published]); err != nil {
+			return n, err
+		}
+		m.published++
+		n++
+	}
+	return n, nil
+}
+
+func (m *outboxMock) PurgePublished(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *outboxMock) pending() []domain.OutboxEvent {
+	return m.events[m.published:]
+}
+
+func outboxEvents(n int) []domain.OutboxEvent {
+	events := make([]domain.OutboxEvent, n)
+	for i := range events {
+		events[i] = domain.OutboxEvent{ID: int64(i + 1), Change: domain.ChangeEvent{Type: domain.ChangeDeleted, TaskID: int64(i + 1)}}
+	}
+	return events
+}
+
+func TestOutboxDispatcher_PublishesInOrder(t *testing.T) {
+	repo := &outboxMock{events: outboxEvents(outboxBatch + 5)}
+
+	var handled []int64
+	record := func(e domain.OutboxEvent) error {
+		handled = append(handled, e.ID)
+		return nil
+	}
+
+	n, err := NewOutboxDispatcher(repo, record).DispatchPending()
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if n != outboxBatch+5 || len(repo.pending()) != 0 {
+		t.Fatalf("expected every event to be published but got %d (%d pending)", n, len(repo.pending()))
+	}
+	for i, id := range handled {
+		if id != int64(i+1) {
+			t.Fatalf("expected events in order but got %v", handled)
+		}
+	}
+}
+
+func TestOutboxDispatcher_RetriesFailedEvents(t *testing.T) {
+	repo := &outboxMock{events: outboxEvents(3)}
+
+	failing := true
+	var handled []int64
+	handler := func(e domain.OutboxEvent) error {
+		if e.ID == 2 && failing {
+			return errors.New("receiver down")
+		}
+		handled = append(handled, e.ID)
+		return nil
+	}
+	dispatcher := NewOutboxDispatcher(repo, handler)
+
+	if n, err := dispatcher.DispatchPending(); err == nil || n != 1 {
+		t.Fatalf("expected to stop at the failed event but published %d (%v)", n, err)
+	}
+	if pending := repo.pending(); len(pending) != 2 || pending[0].ID != 2 {
+		t.Fatalf("expected events 2 and 3 to stay pending but got %+v", pending)
+	}
+
+	failing = false
+	if n, err := dispatcher.DispatchPending(); err != nil || n != 2 {
+		t.Fatalf("expected the remaining events to be published but got %d (%v)", n, err)
+	}
+	if len(handled) != 3 || handled[1] != 2 || handled[2] != 3 {
+		t.Errorf("expected events in order after the retry but got %v", handled)
+	}
+}
+
+func TestOutboxFeed_FollowsOutbox(t *testing.T) {
+	repo := &outboxMock{events: outboxEvents(10)}
+	broker := NewBroker(3)
+	feed := NewOutboxFeed(repo, broker, 3)
+
+	// The first poll replays the backlog, so clients can resume across a
+	// restart.
+	if n, err := feed.Poll(); err != nil || n != 3 {
+		t.Fatalf("expected the last 3 events to be replayed but got %d (%v)", n, err)
+	}
+	missed, events, complete, cancel := broker.Subscribe(8, true)
+	defer cancel()
+	if !complete || len(missed) != 2 || missed[0].ID != 9 || missed[0].TaskID != 9 {
+		t.Errorf("expected events 9 and 10 with their outbox ids but got %+v (complete %v)", missed, complete)
+	}
+
+	repo.events = append(repo.events, domain.OutboxEvent{ID: 12, Change: domain.ChangeEvent{Type: domain.ChangeDeleted, TaskID: 12}})
+	if n, err := feed.Poll(); err != nil || n != 1 {
+		t.Fatalf("expected the new event to be published but got %d (%v)", n, err)
+	}
+	if got := <-events; got.ID != 12 {
+		t.Errorf("expected event 12 but got %+v", got)
+	}
+	if n, err := feed.Poll(); err != nil || n != 0 {
+		t.Errorf("expected nothing new but got %d (%v)", n, err)
+	}
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"prova-fattocs/internal/infra/repository"
+	"time"
+)
+
+// OutboxFeed follows the outbox into a broker. Every process runs its own, so
+// the live clients of every instance receive every change, in commit order,
+// with the outbox id as event ID.
+type OutboxFeed struct {
+	repo    repository.OutboxRepository
+	broker  *Broker
+	backlog int
+	last    int64
+	started bool
+}
+
+// NewOutboxFeed returns a feed that starts backlog changes back, so clients
+// can resume across a restart while the changes they missed are buffered.
+func NewOutboxFeed(repo repository.OutboxRepository, broker *Broker, backlog int) *OutboxFeed {
+	return &OutboxFeed{repo: repo, broker: broker, backlog: backlog}
+}
+
+// Poll publishes the changes committed since the last poll and returns how
+// many were published.
+func (f *OutboxFeed) Poll() (int, error) {
+	if !f.started {
+		last, err := f.repo.LastID()
+		if err != nil {
+			return 0, err
+		}
+		f.last = max(last-int64(f.backlog), 0)
+		f.broker.Reset(uint64(f.last))
+		f.started = true
+	}
+
+	total := 0
+	for {
+		events, err := f.repo.After(f.last, outboxBatch)
+		if err != nil {
+			return total, err
+		}
+		for _, event := range events {
+			change := event.Change
+			change.ID = uint64(event.ID)
+			f.broker.Publish(change)
+			f.last = event.ID
+		}
+		total += len(events)
+		if len(events) < outboxBatch {
+			return total, nil
+		}
+	}
+}
+
+// RunOutboxFeed polls the outbox every interval until ctx is cancelled.
+func RunOutboxFeed(ctx context.Context, f *OutboxFeed, interval time.Duration) {
+	slog.Info("Starting outbox feed", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := f.Poll(); err != nil {
+			slog.Error("Failed to poll outbox", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping outbox feed")
+			return
+		case <-ticker.C:
+		}
+	}
+}
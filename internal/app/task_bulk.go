@@ -0,0 +1,134 @@
+package app
+
+import (
+	"fmt"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+)
+
+// Bulk applies ops in order with the same validation and duplicate-name rules
+// as Create, Update and Delete; names must also be unique within the batch,
+// except that updates of one task may all keep the same name.
+// When atomic is set every operation shares one transaction and any failure
+// rolls them all back, leaving domain.ErrBulkAborted on the operations that
+// did not fail themselves. Otherwise each operation is applied on its own.
+// The returned error is only set when the batch as a whole failed.
+func (s *TaskService) Bulk(ops []domain.BulkOperation, atomic bool) ([]domain.BulkResult, error) {
+	if len(ops) == 0 || len(ops) > domain.MaxBulkOperations {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "operations",
+			Code:    "out_of_range",
+			Message: fmt.Sprintf("operations must hold between 1 and %d items", domain.MaxBulkOperations),
+		})
+	}
+
+	results := make([]domain.BulkResult, len(ops))
+	names := make(map[string]int64, len(ops))
+	failed := false
+	for i := range ops {
+		results[i] = domain.BulkResult{Action: ops[i].Action, ID: ops[i].ID}
+		results[i].Err = checkBulkOperation(&ops[i], names)
+		failed = failed || results[i].Err != nil
+	}
+
+	if !atomic {
+		for i := range ops {
+			if results[i].Err == nil {
+				s.applyBulkOperation(ops[i], &results[i])
+			}
+		}
+		return results, nil
+	}
+
+	if !failed {
+		err := s.repo.Transaction(func(repo repository.TaskRepository) error {
+			tx := s.inTransaction(repo)
+			for i := range ops {
+				if err := tx.applyBulkOperation(ops[i], &results[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			return results, nil
+		}
+
+		failed = false
+		for _, result := range results {
+			failed = failed || result.Err != nil
+		}
+		if !failed {
+			return nil, err
+		}
+	}
+
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = domain.BulkResult{Action: ops[i].Action, ID: ops[i].ID, Err: domain.ErrBulkAborted}
+		}
+	}
+	return results, nil
+}
+
+// checkBulkOperation validates op before anything is applied, normalizing its
+// task and recording its name in names against the ID of the task taking it,
+// 0 for a create. Updates of the same task may repeat its name.
+func checkBulkOperation(op *domain.BulkOperation, names map[string]int64) error {
+	if op.Err != nil {
+		return op.Err
+	}
+
+	switch op.Action {
+	case domain.BulkCreate, domain.BulkUpdate:
+		if op.Action == domain.BulkUpdate && op.ID <= 0 {
+			return domain.NewValidationError(domain.FieldError{Field: "id", Code: "required", Message: "id is required"})
+		}
+		op.Task.Normalize()
+		if err := op.Task.Validate(); err != nil {
+			return err
+		}
+		var id int64
+		if op.Action == domain.BulkUpdate {
+			id = op.ID
+		}
+		if owner, ok := names[op.Task.Name]; ok && (owner == 0 || owner != id) {
+			return domain.ErrDuplicateName
+		}
+		names[op.Task.Name] = id
+	case domain.BulkDelete:
+		if op.ID <= 0 {
+			return domain.NewValidationError(domain.FieldError{Field: "id", Code: "required", Message: "id is required"})
+		}
+	default:
+		return domain.NewValidationError(domain.FieldError{
+			Field:   "action",
+			Code:    "invalid_value",
+			Message: fmt.Sprintf("invalid action %q: expected one of create, update, delete", op.Action),
+		})
+	}
+	return nil
+}
+
+// applyBulkOperation applies op, recording the outcome in result, and returns
+// its error.
+func (s *TaskService) applyBulkOperation(op domain.BulkOperation, result *domain.BulkResult) error {
+	var task domain.Task
+	var err error
+	switch op.Action {
+	case domain.BulkCreate:
+		task, err = s.Create(op.Task)
+	case domain.BulkUpdate:
+		op.Task.Version = op.Version
+		task, err = s.Update(op.ID, op.Task)
+	case domain.BulkDelete:
+		err = s.Delete(op.ID, op.Version)
+	}
+
+	result.Err = err
+	if err == nil && op.Action != domain.BulkDelete {
+		result.ID = task.ID
+		result.Task = &task
+	}
+	return err
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"errors"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+	"prova-fattocs/internal/mocks"
+	"testing"
+	"time"
+)
+
+func newBulkMock(created *[]string) *mocks.TaskRepositoryMock {
+	var m *mocks.TaskRepositoryMock
+	m = &mocks.TaskRepositoryMock{
+		TransactionFunc: func(fn func(repo repository.TaskRepository) error) error {
+			return fn(m)
+		},
+		ExistsByNameFunc: func(name string, id int64) (bool, error) {
+			return name == "Existing", nil
+		},
+		CreateFunc: func(task domain.Task) (domain.Task, error) {
+			*created = append(*created, task.Name)
+			task.ID = int64(len(*created))
+			return task, nil
+		},
+		DeleteFunc: func(id int64, version int64) error {
+			if id == 404 {
+				return domain.ErrNotFound
+			}
+			return nil
+		},
+	}
+	return m
+}
+
+func bulkCreate(name string) domain.BulkOperation {
+	return domain.BulkOperation{
+		Action: domain.BulkCreate,
+		Task:   domain.Task{Name: name, Cost: 500_00, Deadline: domain.NewDate(2025, time.August, 10)},
+	}
+}
+
+func TestBulk_AtomicSuccess(t *testing.T) {
+	var created []string
+	service := NewTaskService(newBulkMock(&created))
+
+	results, err := service.Bulk([]domain.BulkOperation{
+		bulkCreate("Task 1"),
+		bulkCreate("Task 2"),
+		{Action: domain.BulkDelete, ID: 7},
+	}, true)
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Errorf("expected item %d to succeed but got %v", i, result.Err)
+		}
+	}
+	if results[1].Task == nil || results[1].ID != 2 {
+		t.Errorf("expected the second task to be created with ID 2 but got %+v", results[1])
+	}
+}
+
+func TestBulk_AtomicDuplicateWithinBatch(t *testing.T) {
+	var created []string
+	service := NewTaskService(newBulkMock(&created))
+
+	results, err := service.Bulk([]domain.BulkOperation{
+		bulkCreate("Task 1"),
+		bulkCreate(" Task 1 "),
+	}, true)
+	if err != nil {
+		t.Fatalf("expected per-item results but got error: %v", err)
+	}
+	if len(created) != 0 {
+		t.Errorf("expected nothing to be applied but got %v", created)
+	}
+	if !errors.Is(results[0].Err, domain.ErrBulkAborted) {
+		t.Errorf("expected the first item to be aborted but got %v", results[0].Err)
+	}
+	if !errors.Is(results[1].Err, domain.ErrDuplicateName) {
+		t.Errorf("expected duplicate name error but got %v", results[1].Err)
+	}
+}
+
+func TestBulk_RepeatedUpdatesOfOneTask(t *testing.T) {
+	var created []string
+	var updated []int64
+	mockRepo := newBulkMock(&created)
+	mockRepo.UpdateFunc = func(id int64, task domain.Task) (domain.Task, error) {
+		updated = append(updated, id)
+		task.ID = id
+		return task, nil
+	}
+	service := NewTaskService(mockRepo)
+
+	update := func(id int64, name string) domain.BulkOperation {
+		op := bulkCreate(name)
+		op.Action, op.ID = domain.BulkUpdate, id
+		return op
+	}
+	results, err := service.Bulk([]domain.BulkOperation{
+		update(7, "Task 1"),
+		update(7, "Task 1 "),
+		update(8, "Task 1"),
+		bulkCreate("Task 1"),
+	}, false)
+	if err != nil {
+		t.Fatalf("expected per-item results but got error: %v", err)
+	}
+	for i := range 2 {
+		if results[i].Err != nil {
+			t.Errorf("expected update %d of task 7 to keep its name but got %v", i, results[i].Err)
+		}
+	}
+	for i := 2; i < 4; i++ {
+		if !errors.Is(results[i].Err, domain.ErrDuplicateName) {
+			t.Errorf("expected item %d to clash with task 7 but got %v", i, results[i].Err)
+		}
+	}
+	if len(updated) != 2 || len(created) != 0 {
+		t.Errorf("expected only task 7 to be written but got updates %v and creates %v", updated, created)
+	}
+}
+
+func TestBulk_AtomicRollsBackOnFailure(t *testing.T) {
+	var created []string
+	service := NewTaskService(newBulkMock(&created))
+
+	results, err := service.Bulk([]domain.BulkOperation{
+		bulkCreate("Task 1"),
+		{Action: domain.BulkDelete, ID: 404},
+		bulkCreate("Task 2"),
+	}, true)
+	if err != nil {
+		t.Fatalf("expected per-item results but got error: %v", err)
+	}
+	if !errors.Is(results[0].Err, domain.ErrBulkAborted) || results[0].Task != nil {
+		t.Errorf("expected the applied create to be reported as aborted but got %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, domain.ErrNotFound) {
+		t.Errorf("expected not found error but got %v", results[1].Err)
+	}
+	if !errors.Is(results[2].Err, domain.ErrBulkAborted) {
+		t.Errorf("expected the last item to be aborted but got %v", results[2].Err)
+	}
+}
+
+func TestBulk_AtomicUpdateOfLockedTask(t *testing.T) {
+	var created []string
+	mockRepo := newBulkMock(&created)
+	mockRepo.WithAuditFunc = func(info domain.AuditInfo) repository.TaskRepository { return mockRepo }
+	mockRepo.UpdateFunc = func(id int64, task domain.Task) (domain.Task, error) {
+		t.Errorf("expected the locked task not to be updated")
+		return task, nil
+	}
+
+	collab := NewCollaboration(time.Minute)
+	ana := collab.Join("ana")
+	defer ana.Leave()
+	if _, err := ana.Lock(7); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	service := NewTaskService(mockRepo).WithCollaboration(collab).WithAudit(domain.AuditInfo{Actor: "bruno"})
+
+	update := bulkCreate("Task 7")
+	update.Action, update.ID = domain.BulkUpdate, 7
+	results, err := service.Bulk([]domain.BulkOperation{bulkCreate("Task 1"), update}, true)
+	if err != nil {
+		t.Fatalf("expected per-item results but got error: %v", err)
+	}
+	if !errors.Is(results[1].Err, domain.ErrLocked) {
+		t.Errorf("expected ErrLocked but got %v", results[1].Err)
+	}
+	if !errors.Is(results[0].Err, domain.ErrBulkAborted) {
+		t.Errorf("expected the create to be aborted but got %v", results[0].Err)
+	}
+}
+
+func TestBulk_BestEffort(t *testing.T) {
+	var created []string
+	service := NewTaskService(newBulkMock(&created))
+
+	results, err := service.Bulk([]domain.BulkOperation{
+		bulkCreate("Existing"),
+		bulkCreate("Task 1"),
+		{Action: "archive", ID: 1},
+		{Action: domain.BulkUpdate, Task: domain.Task{Name: "Task 2"}},
+	}, false)
+	if err != nil {
+		t.Fatalf("expected per-item results but got error: %v", err)
+	}
+	if !errors.Is(results[0].Err, domain.ErrDuplicateName) {
+		t.Errorf("expected duplicate name error but got %v", results[0].Err)
+	}
+	if results[1].Err != nil || len(created) != 1 {
+		t.Errorf("expected Task 1 to be created but got %v", results[1].Err)
+	}
+	if !errors.Is(results[2].Err, domain.ErrValidation) {
+		t.Errorf("expected validation error for unknown action but got %v", results[2].Err)
+	}
+	if !errors.Is(results[3].Err, domain.ErrValidation) {
+		t.Errorf("expected validation error for update without id but got %v", results[3].Err)
+	}
+}
+
+func TestBulk_Empty(t *testing.T) {
+	service := NewTaskService(&mocks.TaskRepositoryMock{})
+
+	_, err := service.Bulk(nil, true)
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected validation error but got %v", err)
+	}
+}
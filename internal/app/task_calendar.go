@@ -0,0 +1,86 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"prova-fattocs/internal/domain"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// calendarProductID identifies this server as the producer of the calendar.
+const calendarProductID = "-//prova-fattocs//Task Deadlines//EN"
+
+// icsEscaper escapes TEXT property values (RFC 5545, section 3.3.11).
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// LastChange returns the ID and time of the latest change to any task, or
+// zero values if nothing has changed yet.
+func (s *TaskService) LastChange() (int64, time.Time, error) {
+	return s.repo.LastChange()
+}
+
+// ExportCalendar writes the tasks selected by opts as an RFC 5545 calendar
+// with one all-day event per deadline. Pagination in opts is ignored. stamp
+// is used as the DTSTAMP of every event, so the same tasks and stamp always
+// produce the same bytes.
+func (s *TaskService) ExportCalendar(w io.Writer, opts domain.ListOptions, stamp time.Time) error {
+	opts.Limit, opts.Page = 0, 0
+	tasks, _, err := s.List(opts)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeContentLine(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", calendarProductID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", "Tasks")
+	for _, t := range tasks {
+		deadline := t.Deadline.Time()
+		line("BEGIN", "VEVENT")
+		line("UID", fmt.Sprintf("task-%d@prova-fattocs", t.ID))
+		line("DTSTAMP", stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE", deadline.Format("20060102"))
+		line("DTEND;VALUE=DATE", deadline.AddDate(0, 0, 1).Format("20060102"))
+		line("SEQUENCE", fmt.Sprint(t.Version))
+		line("SUMMARY", icsEscaper.Replace(t.Name))
+		line("DESCRIPTION", icsEscaper.Replace("Cost: "+t.Cost.String()))
+		line("TRANSP", "TRANSPARENT")
+		if t.Status == domain.StatusCancelled {
+			line("STATUS", "CANCELLED")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	return bw.Flush()
+}
+
+// writeContentLine writes a content line terminated by CRLF, folding it so
+// no physical line exceeds 75 octets without splitting a UTF-8 sequence.
+func writeContentLine(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards the
+		// limit.
+		limit = 74
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"bytes"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportCalendar(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ListFunc: func(opts domain.ListOptions) ([]domain.Task, int, error) {
+			if opts.Limit != 0 {
+				t.Errorf("expected every task to be listed but got limit %d", opts.Limit)
+			}
+			return []domain.Task{
+				{ID: 7, Name: "Revisar contrato, anexo; v2", Cost: 1234_05, Deadline: domain.NewDate(2025, time.December, 31), Version: 3},
+				{ID: 8, Name: "Cancelada", Cost: 0, Deadline: domain.NewDate(2025, time.August, 10), Status: domain.StatusCancelled, Version: 1},
+			}, 2, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	var buf bytes.Buffer
+	stamp := time.Date(2025, time.August, 1, 12, 30, 0, 0, time.UTC)
+	if err := service.ExportCalendar(&buf, domain.ListOptions{Limit: 10}, stamp); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"UID:task-7@prova-fattocs\r\n",
+		"DTSTAMP:20250801T123000Z\r\n",
+		"DTSTART;VALUE=DATE:20251231\r\nDTEND;VALUE=DATE:20260101\r\n",
+		`SUMMARY:Revisar contrato\, anexo\; v2` + "\r\n",
+		"DESCRIPTION:Cost: 1234.05\r\n",
+		"STATUS:CANCELLED\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected calendar to contain %q but got:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "BEGIN:VEVENT") != 2 {
+		t.Errorf("expected 2 events but got:\n%s", out)
+	}
+}
+
+func TestExportCalendar_FoldsLongLines(t *testing.T) {
+	name := strings.Repeat("ação ", 40)
+	mockRepo := &mocks.TaskRepositoryMock{
+		ListFunc: func(opts domain.ListOptions) ([]domain.Task, int, error) {
+			return []domain.Task{{ID: 1, Name: name, Deadline: domain.NewDate(2025, time.August, 10)}}, 1, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	var buf bytes.Buffer
+	if err := service.ExportCalendar(&buf, domain.ListOptions{}, time.Now()); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("expected lines of at most 75 octets but got %d: %q", len(line), line)
+		}
+	}
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+name+"\r\n") {
+		t.Errorf("expected the folded summary to unfold to the task name")
+	}
+}
@@ -0,0 +1,285 @@
+package app
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+	"strconv"
+	"strings"
+)
+
+// csvHeader is the header row written by ExportCSV. ImportCSV requires the
+// name, cost and deadline columns, in any order, and ignores the others.
+var csvHeader = []string{"name", "cost", "deadline", "order"}
+
+// exportBatchSize is how many tasks ExportCSV reads at a time.
+const exportBatchSize = 500
+
+// ExportCSV writes the tasks selected by the filters and sort of opts as CSV,
+// one row per task after a header row. Pagination in opts is ignored: tasks
+// are read and written in batches, so memory use does not grow with the
+// number of tasks. Nothing is written if reading the first batch fails.
+func (s *TaskService) ExportCSV(w io.Writer, opts domain.ListOptions, format domain.CSVFormat) error {
+	if err := format.Validate(); err != nil {
+		return err
+	}
+	opts.Limit, opts.Page = 0, 0
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = format.Delimiter
+	var after *domain.Task
+	for {
+		tasks, err := s.repo.ListAfter(opts, after, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		if after == nil {
+			if err := cw.Write(csvHeader); err != nil {
+				return err
+			}
+		}
+		for _, t := range tasks {
+			record := []string{csvEscape(t.Name), csvEscape(format.FormatMoney(t.Cost)), t.Deadline.String(), strconv.Itoa(t.OrderNumber)}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		if len(tasks) < exportBatchSize {
+			return nil
+		}
+		after = &tasks[len(tasks)-1]
+	}
+}
+
+// ImportCSV creates or updates one task per CSV row, matching existing tasks
+// by name. Every row is validated before anything is saved; if any row is
+// invalid, or saving one fails, nothing is saved and the other rows report
+// domain.ErrBulkAborted. The returned error is only set when the file as a
+// whole cannot be read.
+func (s *TaskService) ImportCSV(r io.Reader, format domain.CSVFormat) ([]domain.ImportRowResult, error) {
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(r)
+	cr.Comma = format.Delimiter
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fileError("empty", "the file has no header row")
+	} else if err != nil {
+		return nil, fileError("invalid_format", err.Error())
+	}
+	columns, err := csvColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.ImportRowResult
+	var tasks []domain.Task
+	names := make(map[string]bool)
+	failed := false
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fileError("invalid_format", err.Error())
+		}
+		if len(results) == domain.MaxImportRows {
+			return nil, fileError("too_many_rows", fmt.Sprintf("the file must hold at most %d rows", domain.MaxImportRows))
+		}
+
+		line, _ := cr.FieldPos(0)
+		task, err := csvTask(record, columns, format)
+		if err == nil {
+			if names[task.Name] {
+				err = domain.ErrDuplicateName
+			}
+			names[task.Name] = true
+		}
+
+		results = append(results, domain.ImportRowResult{Row: line, Name: task.Name, Err: err})
+		tasks = append(tasks, task)
+		failed = failed || err != nil
+	}
+	if len(results) == 0 {
+		return nil, fileError("empty", "the file has no rows")
+	}
+
+	if !failed {
+		err := s.repo.Transaction(func(repo repository.TaskRepository) error {
+			tx := s.inTransaction(repo)
+			for i := range tasks {
+				if err := tx.upsertRow(tasks[i], &results[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			return results, nil
+		}
+
+		for _, result := range results {
+			failed = failed || result.Err != nil
+		}
+		if !failed {
+			return nil, err
+		}
+	}
+
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = domain.ImportRowResult{Row: results[i].Row, Name: results[i].Name, Err: domain.ErrBulkAborted}
+		}
+	}
+	return results, nil
+}
+
+// upsertRow saves task under its name, recording the outcome in result, and
+// returns its error.
+func (s *TaskService) upsertRow(task domain.Task, result *domain.ImportRowResult) error {
+	existing, err := s.repo.GetByName(task.Name)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		task, err = s.Create(task)
+		result.Action = domain.ImportCreated
+	case err != nil:
+	case existing.Cost == task.Cost && existing.Deadline == task.Deadline:
+		task = existing
+		result.Action = domain.ImportUnchanged
+	default:
+		task.Version = existing.Version
+		task, err = s.Update(existing.ID, task)
+		result.Action = domain.ImportUpdated
+	}
+
+	if err != nil {
+		result.Action = ""
+		result.Err = err
+		return err
+	}
+	result.Task = &task
+	return nil
+}
+
+// csvColumns maps the required column names onto their index in header.
+func csvColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if i == 0 {
+			// Spreadsheets often save UTF-8 with a byte order mark.
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		if _, seen := columns[name]; !seen {
+			columns[name] = i
+		}
+	}
+
+	var missing []string
+	for _, name := range csvHeader[:3] {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fileError("missing_column", "the header must have the columns "+strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// csvTask parses and validates the task in record, reporting every invalid
+// field together.
+func csvTask(record []string, columns map[string]int, format domain.CSVFormat) (domain.Task, error) {
+	field := func(name string) string {
+		if i := columns[name]; i < len(record) {
+			return csvUnescape(strings.TrimSpace(record[i]))
+		}
+		return ""
+	}
+
+	task := domain.Task{Name: field("name")}
+	task.Normalize()
+
+	var fields []domain.FieldError
+	cost, err := format.ParseMoney(field("cost"))
+	if err != nil {
+		fields = append(fields, domain.MoneyFieldError("cost", err))
+	} else {
+		task.Cost = cost
+	}
+	deadline, err := domain.ParseDate(field("deadline"))
+	if err != nil {
+		fields = append(fields, domain.FieldError{Field: "deadline", Code: "invalid_format", Message: err.Error()})
+	} else {
+		task.Deadline = deadline
+	}
+
+	// Fields that failed to parse were left zero; do not report them twice.
+	parsed := func(name string) bool {
+		for _, f := range fields {
+			if f.Field == name {
+				return false
+			}
+		}
+		return true
+	}
+	var validationErr *domain.ValidationError
+	if err := task.Validate(); errors.As(err, &validationErr) {
+		for _, f := range validationErr.Fields {
+			if parsed(f.Field) {
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return task, domain.NewValidationError(fields...)
+	}
+	return task, nil
+}
+
+// formulaPrefixes are the characters spreadsheets read as the start of a
+// formula at the beginning of a cell.
+const formulaPrefixes = "=+-@\t\r"
+
+// csvEscape prefixes value with a quote if a spreadsheet would otherwise run
+// it as a formula. Values already starting with a quote get another one, so
+// csvUnescape restores every value exactly.
+func csvEscape(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes+"'", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// csvUnescape removes one quote from values csvEscape could have produced by
+// adding it: those where a quote is followed by a formula character or by
+// another quote. Other values, including ones starting with a quote followed
+// by anything else, are returned unchanged, so csvUnescape(csvEscape(v)) is
+// always v.
+func csvUnescape(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes+"'", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+func fileError(code, message string) error {
+	return domain.NewValidationError(domain.FieldError{Field: "file", Code: code, Message: message})
+}
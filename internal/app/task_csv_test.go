@@ -0,0 +1,267 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+	"prova-fattocs/internal/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+var brazilianCSV = domain.CSVFormat{Delimiter: ';', Decimal: ','}
+
+func TestExportCSV(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ListAfterFunc: func(opts domain.ListOptions, after *domain.Task, limit int) ([]domain.Task, error) {
+			if after != nil {
+				t.Errorf("expected a single batch but was asked for tasks after %+v", after)
+			}
+			return []domain.Task{
+				{ID: 1, Name: "Relatório; final", Cost: 1234_05, Deadline: domain.NewDate(2025, time.August, 10), OrderNumber: 1},
+			}, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	var buf bytes.Buffer
+	if err := service.ExportCSV(&buf, domain.ListOptions{}, brazilianCSV); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	want := "name;cost;deadline;order\n\"Relatório; final\";1234,05;2025-08-10;1\n"
+	if buf.String() != want {
+		t.Errorf("expected %q but got %q", want, buf.String())
+	}
+}
+
+func TestExportCSV_ReadsInBatches(t *testing.T) {
+	total := 2*exportBatchSize + 1
+	deadline := domain.NewDate(2025, time.August, 10)
+	batches := 0
+	mockRepo := &mocks.TaskRepositoryMock{
+		ListAfterFunc: func(opts domain.ListOptions, after *domain.Task, limit int) ([]domain.Task, error) {
+			if opts.Limit != 0 || opts.Page != 0 {
+				t.Errorf("expected pagination to be ignored but got limit %d, page %d", opts.Limit, opts.Page)
+			}
+			start := 0
+			if after != nil {
+				start = after.OrderNumber
+			}
+			if start != batches*exportBatchSize {
+				t.Errorf("batch %d: expected to continue after task %d but got %d", batches, batches*exportBatchSize, start)
+			}
+			batches++
+
+			var tasks []domain.Task
+			for i := start + 1; i <= total && len(tasks) < limit; i++ {
+				tasks = append(tasks, domain.Task{ID: int64(i), Name: fmt.Sprintf("Task %d", i), Deadline: deadline, OrderNumber: i})
+			}
+			return tasks, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	var buf bytes.Buffer
+	if err := service.ExportCSV(&buf, domain.ListOptions{Limit: 10, Page: 2}, domain.CSVFormat{Delimiter: ',', Decimal: '.'}); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if batches != 3 {
+		t.Errorf("expected 3 batches but got %d", batches)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != total+1 {
+		t.Errorf("expected a header and %d rows but got %d lines", total, lines)
+	}
+}
+
+func TestExportCSV_FailedQueryWritesNothing(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ListAfterFunc: func(opts domain.ListOptions, after *domain.Task, limit int) ([]domain.Task, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	var buf bytes.Buffer
+	if err := service.ExportCSV(&buf, domain.ListOptions{}, brazilianCSV); err == nil {
+		t.Error("expected error but got nil")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing to be written but got %q", buf.String())
+	}
+}
+
+func TestCSV_EscapesFormulas(t *testing.T) {
+	names := []string{`=HYPERLINK("http://example.com")`, "+cmd|' /C calc'!A0", "-2+3", "@SUM(A1)", "'quoted", "'=x", "''x", "'-x", "Plain"}
+	deadline := domain.NewDate(2025, time.August, 10)
+
+	var m *mocks.TaskRepositoryMock
+	m = &mocks.TaskRepositoryMock{
+		ListAfterFunc: func(opts domain.ListOptions, after *domain.Task, limit int) ([]domain.Task, error) {
+			var tasks []domain.Task
+			for i, name := range names {
+				tasks = append(tasks, domain.Task{ID: int64(i + 1), Name: name, Cost: 1_00, Deadline: deadline, OrderNumber: i + 1})
+			}
+			return tasks, nil
+		},
+		TransactionFunc: func(fn func(repo repository.TaskRepository) error) error {
+			return fn(m)
+		},
+		GetByNameFunc: func(name string) (domain.Task, error) {
+			return domain.Task{}, domain.ErrNotFound
+		},
+		ExistsByNameFunc: func(name string, id int64) (bool, error) {
+			return false, nil
+		},
+		CreateFunc: func(task domain.Task) (domain.Task, error) {
+			return task, nil
+		},
+	}
+
+	service := NewTaskService(m)
+
+	var buf bytes.Buffer
+	if err := service.ExportCSV(&buf, domain.ListOptions{}, brazilianCSV); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	for _, line := range strings.Split(buf.String(), "\n")[1:] {
+		if line != "" && strings.ContainsAny(line[:1], "=+-@") {
+			t.Errorf("expected formulas to be escaped but got %q", line)
+		}
+	}
+
+	results, err := service.ImportCSV(&buf, brazilianCSV)
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	for i, result := range results {
+		if result.Err != nil || result.Name != names[i] {
+			t.Errorf("expected %q back but got %q (error %v)", names[i], result.Name, result.Err)
+		}
+	}
+}
+
+func TestCSVEscape_RoundTrip(t *testing.T) {
+	tests := []struct {
+		value   string
+		escaped string
+	}{
+		{"Plain", "Plain"},
+		{"=x", "'=x"},
+		{"-draft", "'-draft"},
+		{"\tTabbed", "'\tTabbed"},
+		{"'", "''"},
+		{"'quoted", "''quoted"},
+		{"'=x", "''=x"},
+		{"''x", "'''x"},
+		{"'-x", "''-x"},
+	}
+	for _, tt := range tests {
+		if got := csvEscape(tt.value); got != tt.escaped {
+			t.Errorf("csvEscape(%q): expected %q but got %q", tt.value, tt.escaped, got)
+		}
+		if got := csvUnescape(tt.escaped); got != tt.value {
+			t.Errorf("csvUnescape(%q): expected %q but got %q", tt.escaped, tt.value, got)
+		}
+	}
+
+	// A quote before an ordinary character was not added by csvEscape.
+	if got := csvUnescape("'quoted"); got != "'quoted" {
+		t.Errorf("expected %q to be kept but got %q", "'quoted", got)
+	}
+}
+
+func TestImportCSV_Upserts(t *testing.T) {
+	deadline := domain.NewDate(2025, time.August, 10)
+	existing := map[string]domain.Task{
+		"Same":    {ID: 1, Name: "Same", Cost: 10_00, Deadline: deadline, Version: 3},
+		"Changed": {ID: 2, Name: "Changed", Cost: 10_00, Deadline: deadline, Version: 4},
+	}
+
+	var m *mocks.TaskRepositoryMock
+	m = &mocks.TaskRepositoryMock{
+		TransactionFunc: func(fn func(repo repository.TaskRepository) error) error {
+			return fn(m)
+		},
+		GetByNameFunc: func(name string) (domain.Task, error) {
+			if task, ok := existing[name]; ok {
+				return task, nil
+			}
+			return domain.Task{}, domain.ErrNotFound
+		},
+		ExistsByNameFunc: func(name string, id int64) (bool, error) {
+			return false, nil
+		},
+		CreateFunc: func(task domain.Task) (domain.Task, error) {
+			task.ID = 3
+			return task, nil
+		},
+		UpdateFunc: func(id int64, task domain.Task) (domain.Task, error) {
+			if task.Version != 4 {
+				t.Errorf("expected the update to be conditional on version 4 but got %d", task.Version)
+			}
+			task.ID = id
+			return task, nil
+		},
+	}
+
+	service := NewTaskService(m)
+
+	input := "\ufeffName;Cost;Deadline\nSame;10,00;2025-08-10\nChanged;12,50;2025-08-10\nNew;1;2025-08-10\n"
+	results, err := service.ImportCSV(strings.NewReader(input), brazilianCSV)
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+
+	want := []domain.ImportAction{domain.ImportUnchanged, domain.ImportUpdated, domain.ImportCreated}
+	for i, result := range results {
+		if result.Err != nil || result.Action != want[i] {
+			t.Errorf("row %d: expected %s but got %s (error %v)", result.Row, want[i], result.Action, result.Err)
+		}
+	}
+	if results[1].Task.Cost != 12_50 {
+		t.Errorf("expected cost 12.50 but got %v", results[1].Task.Cost)
+	}
+}
+
+func TestImportCSV_RowErrors(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{}
+
+	service := NewTaskService(mockRepo)
+
+	input := "name;cost;deadline\nA;1,5;2025-08-10\nB;1.5;2025-13-10\nA;2;2025-08-10\n"
+	results, err := service.ImportCSV(strings.NewReader(input), brazilianCSV)
+	if err != nil {
+		t.Fatalf("expected row errors but got error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 rows but got %d", len(results))
+	}
+
+	if !errors.Is(results[0].Err, domain.ErrBulkAborted) {
+		t.Errorf("expected the valid row not to be saved but got %v", results[0].Err)
+	}
+	var validationErr *domain.ValidationError
+	if !errors.As(results[1].Err, &validationErr) || len(validationErr.Fields) != 2 || results[1].Row != 3 {
+		t.Errorf("expected cost and deadline errors on row 3 but got %+v", results[1])
+	}
+	if !errors.Is(results[2].Err, domain.ErrDuplicateName) {
+		t.Errorf("expected duplicate name error but got %v", results[2].Err)
+	}
+}
+
+func TestImportCSV_MissingColumn(t *testing.T) {
+	service := NewTaskService(&mocks.TaskRepositoryMock{})
+
+	_, err := service.ImportCSV(strings.NewReader("name,cost\nA,1\n"), domain.DefaultCSVFormat)
+
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Code != "missing_column" {
+		t.Errorf("expected missing column error but got %v", err)
+	}
+}
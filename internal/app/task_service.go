@@ -1,49 +1,287 @@
 package app
 
 import (
-	"errors"
+	"fmt"
 	"prova-fattocs/internal/domain"
 	"prova-fattocs/internal/infra/repository"
+	"strings"
+	"time"
 )
 
 type TaskService struct {
-	repo repository.TaskRepository
+	repo      repository.TaskRepository
+	broker    *Broker
+	collab    *Collaboration
+	lockToken string
 }
 
 func NewTaskService(repo repository.TaskRepository) *TaskService {
 	return &TaskService{repo: repo}
 }
 
-func (s *TaskService) List() ([]domain.Task, error) {
-	return s.repo.List()
+// WithAudit returns a service whose changes are attributed to info in the
+// audit log.
+func (s *TaskService) WithAudit(info domain.AuditInfo) *TaskService {
+	return &TaskService{repo: s.repo.WithAudit(info), broker: s.broker, collab: s.collab, lockToken: s.lockToken}
 }
 
-func (s *TaskService) Create(task domain.Task) error {
+// WithBroker returns a service whose Subscribe follows the changes
+// published to broker.
+func (s *TaskService) WithBroker(broker *Broker) *TaskService {
+	return &TaskService{repo: s.repo, broker: broker, collab: s.collab, lockToken: s.lockToken}
+}
+
+// WithCollaboration returns a service that refuses to change tasks locked in
+// collab by sessions other than the one given to WithLockToken.
+func (s *TaskService) WithCollaboration(collab *Collaboration) *TaskService {
+	return &TaskService{repo: s.repo, broker: s.broker, collab: collab, lockToken: s.lockToken}
+}
+
+// WithLockToken returns a service writing on behalf of the collaboration
+// session whose token is given, so tasks it has locked can be changed.
+func (s *TaskService) WithLockToken(token string) *TaskService {
+	return &TaskService{repo: s.repo, broker: s.broker, collab: s.collab, lockToken: token}
+}
+
+// inTransaction returns a service writing through repo, the repository
+// handed to a Transaction callback, that keeps the service's lock checks.
+func (s *TaskService) inTransaction(repo repository.TaskRepository) *TaskService {
+	return &TaskService{repo: repo, broker: s.broker, collab: s.collab, lockToken: s.lockToken}
+}
+
+// Subscribe follows the committed changes published to the service's
+// broker; see Broker.Subscribe. Without a broker nothing is ever received.
+func (s *TaskService) Subscribe(lastID uint64, resume bool) ([]domain.ChangeEvent, <-chan domain.ChangeEvent, bool, func()) {
+	if s.broker == nil {
+		return nil, nil, !resume, func() {}
+	}
+	return s.broker.Subscribe(lastID, resume)
+}
+
+// List returns the page of tasks selected by opts and the pagination
+// metadata describing it.
+func (s *TaskService) List(opts domain.ListOptions) ([]domain.Task, domain.Pagination, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, domain.Pagination{}, err
+	}
+
+	tasks, total, err := s.repo.List(opts)
+	if err != nil {
+		return nil, domain.Pagination{}, err
+	}
+	return tasks, domain.NewPagination(opts, total), nil
+}
+
+func (s *TaskService) GetByID(id int64) (domain.Task, error) {
+	return s.repo.GetByID(id)
+}
+
+// Search runs a ranked full-text search over task names. A limit of 0 uses
+// domain.DefaultSearchLimit.
+func (s *TaskService) Search(query string, limit int) ([]domain.SearchResult, error) {
+	query = strings.TrimSpace(query)
+
+	var fields []domain.FieldError
+	if query == "" {
+		fields = append(fields, domain.FieldError{Field: "q", Code: "required", Message: "q is required"})
+	}
+	if limit < 0 || limit > domain.MaxPageSize {
+		fields = append(fields, domain.FieldError{
+			Field:   "limit",
+			Code:    "out_of_range",
+			Message: fmt.Sprintf("limit must not be negative or greater than %d", domain.MaxPageSize),
+		})
+	}
+	if len(fields) > 0 {
+		return nil, domain.NewValidationError(fields...)
+	}
+
+	if limit == 0 {
+		limit = domain.DefaultSearchLimit
+	}
+	return s.repo.Search(query, limit)
+}
+
+func (s *TaskService) Create(task domain.Task) (domain.Task, error) {
+	task.Normalize()
+	if err := task.Validate(); err != nil {
+		return domain.Task{}, err
+	}
+
 	exists, err := s.repo.ExistsByName(task.Name, task.ID)
 	if err != nil {
-		return err
+		return domain.Task{}, err
 	}
 	if exists {
-		return errors.New("task with this name already exists")
+		return domain.Task{}, domain.ErrDuplicateName
 	}
 	return s.repo.Create(task)
 }
 
-func (s *TaskService) Update(id int64, updated domain.Task) error {
+func (s *TaskService) Update(id int64, updated domain.Task) (domain.Task, error) {
+	if err := s.collab.CheckLock(id, s.lockToken); err != nil {
+		return domain.Task{}, err
+	}
+
+	updated.Normalize()
+	if err := updated.Validate(); err != nil {
+		return domain.Task{}, err
+	}
+
 	exists, err := s.repo.ExistsByName(updated.Name, id)
 	if err != nil {
-		return err
+		return domain.Task{}, err
 	}
 	if exists {
-		return errors.New("task with this name already exists")
+		return domain.Task{}, domain.ErrDuplicateName
 	}
 	return s.repo.Update(id, updated)
 }
 
-func (s *TaskService) Delete(id int64) error {
-	return s.repo.Delete(id)
+// Patch applies a partial update. Validation and the duplicate-name check
+// only look at the fields present in the patch.
+func (s *TaskService) Patch(id int64, patch domain.TaskPatch) (domain.Task, error) {
+	if patch.IsEmpty() {
+		return s.repo.GetByID(id)
+	}
+	if err := s.collab.CheckLock(id, s.lockToken); err != nil {
+		return domain.Task{}, err
+	}
+
+	patch.Normalize()
+	if err := patch.Validate(); err != nil {
+		return domain.Task{}, err
+	}
+
+	if patch.Name != nil {
+		exists, err := s.repo.ExistsByName(*patch.Name, id)
+		if err != nil {
+			return domain.Task{}, err
+		}
+		if exists {
+			return domain.Task{}, domain.ErrDuplicateName
+		}
+	}
+	return s.repo.Patch(id, patch)
+}
+
+// Transition moves the task to status if the workflow allows it from the
+// task's current status. A non-zero version makes the move conditional on the
+// task not having changed since it was read.
+func (s *TaskService) Transition(id int64, status domain.Status, version int64) (domain.Task, error) {
+	if err := s.collab.CheckLock(id, s.lockToken); err != nil {
+		return domain.Task{}, err
+	}
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if version != 0 && version != current.Version {
+		return domain.Task{}, domain.ErrPreconditionFailed
+	}
+	if !current.Status.CanTransitionTo(status) {
+		return domain.Task{}, fmt.Errorf("%w: cannot move task from %s to %s", domain.ErrConflict, current.Status, status)
+	}
+	return s.repo.UpdateStatus(id, current.Status, status, version)
+}
+
+// Delete moves the task to the trash. A non-zero version makes the delete
+// conditional on the task not having changed since it was read.
+func (s *TaskService) Delete(id, version int64) error {
+	if err := s.collab.CheckLock(id, s.lockToken); err != nil {
+		return err
+	}
+	return s.repo.Delete(id, version)
+}
+
+func (s *TaskService) ListDeleted() ([]domain.Task, error) {
+	return s.repo.ListDeleted()
+}
+
+// Restore takes the task out of the trash and appends it to the list. A
+// non-zero version makes the restore conditional on the task not having
+// changed since it was read.
+func (s *TaskService) Restore(id, version int64) (domain.Task, error) {
+	if err := s.collab.CheckLock(id, s.lockToken); err != nil {
+		return domain.Task{}, err
+	}
+	return s.repo.Restore(id, version)
+}
+
+// PurgeTrash permanently removes tasks that have been in the trash for longer
+// than retention.
+func (s *TaskService) PurgeTrash(retention time.Duration) (int64, error) {
+	return s.repo.PurgeDeleted(time.Now().Add(-retention))
+}
+
+// ListRevisions returns the saved states of the task, newest first.
+func (s *TaskService) ListRevisions(id int64) ([]domain.TaskRevision, error) {
+	return s.repo.ListRevisions(id)
+}
+
+// Revert restores the name, cost and deadline saved in revision, taking the
+// task out of the trash if needed. A non-zero version makes the revert
+// conditional on the task not having changed since it was read.
+func (s *TaskService) Revert(id int64, revision int, version int64) (domain.Task, error) {
+	if err := s.collab.CheckLock(id, s.lockToken); err != nil {
+		return domain.Task{}, err
+	}
+	if revision < 1 {
+		return domain.Task{}, domain.NewValidationError(domain.FieldError{
+			Field:   "revision",
+			Code:    "out_of_range",
+			Message: "revision must be at least 1",
+		})
+	}
+
+	snapshot, err := s.repo.GetRevision(id, revision)
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	exists, err := s.repo.ExistsByName(snapshot.Name, id)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if exists {
+		return domain.Task{}, domain.ErrDuplicateName
+	}
+	return s.repo.Revert(id, revision, version)
+}
+
+// Reorder moves the task to position. A non-zero version makes the move
+// conditional on the task not having changed since it was read. Only the
+// moved task has to be free of other sessions' locks; the tasks shifted
+// around it keep their fields.
+func (s *TaskService) Reorder(id, position, version int64) error {
+	if err := s.collab.CheckLock(id, s.lockToken); err != nil {
+		return err
+	}
+	return s.repo.Reorder(id, position, version)
+}
+
+// ReorderAll sets the order of every task from ids. A non-empty version
+// makes it conditional on no task having changed since the list whose
+// ListVersion it is was read. It returns the tasks in their new order and the
+// new ListVersion. Locks are not checked: only positions change, and no task
+// is singled out as the moved one.
+func (s *TaskService) ReorderAll(ids []int64, version string) ([]domain.Task, string, error) {
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return nil, "", domain.NewValidationError(domain.FieldError{
+				Field:   "ids",
+				Code:    "duplicate_id",
+				Message: fmt.Sprintf("task %d is listed more than once", id),
+			})
+		}
+		seen[id] = true
+	}
+	return s.repo.ReorderAll(ids, version)
 }
 
-func (s *TaskService) Reorder(id, direction int64) error {
-	return s.repo.Reorder(id, direction)
+// ListVersion identifies the current state of the tasks outside the trash;
+// it changes whenever any of them is created, deleted, edited or moved.
+func (s *TaskService) ListVersion() (string, error) {
+	return s.repo.ListVersion()
 }
@@ -3,8 +3,10 @@ package app
 import (
 	"errors"
 	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
 	"prova-fattocs/internal/mocks"
 	"testing"
+	"time"
 )
 
 func TestCreateTask_Success(t *testing.T) {
@@ -12,8 +14,10 @@ func TestCreateTask_Success(t *testing.T) {
 		ExistsByNameFunc: func(name string, id int64) (bool, error) {
 			return false, nil
 		},
-		CreateFunc: func(task domain.Task) error {
-			return nil
+		CreateFunc: func(task domain.Task) (domain.Task, error) {
+			task.ID = 1
+			task.OrderNumber = 1
+			return task, nil
 		},
 	}
 
@@ -21,14 +25,17 @@ func TestCreateTask_Success(t *testing.T) {
 
 	task := domain.Task{
 		Name:     "New Task",
-		Cost:     500,
-		Deadline: "2025-08-10",
+		Cost:     500_00,
+		Deadline: domain.NewDate(2025, time.August, 10),
 	}
 
-	err := service.Create(task)
+	created, err := service.Create(task)
 	if err != nil {
 		t.Errorf("expected success but got error: %v", err)
 	}
+	if created.ID != 1 || created.OrderNumber != 1 {
+		t.Errorf("expected persisted task but got %+v", created)
+	}
 }
 
 func TestCreateTask_DuplicateName(t *testing.T) {
@@ -42,13 +49,65 @@ func TestCreateTask_DuplicateName(t *testing.T) {
 
 	task := domain.Task{
 		Name:     "Duplicate",
-		Cost:     100,
-		Deadline: "2025-08-10",
+		Cost:     100_00,
+		Deadline: domain.NewDate(2025, time.August, 10),
 	}
 
-	err := service.Create(task)
-	if err == nil {
-		t.Error("expected duplicate name error but got nil")
+	_, err := service.Create(task)
+	if !errors.Is(err, domain.ErrDuplicateName) {
+		t.Errorf("expected duplicate name error but got %v", err)
+	}
+}
+
+func TestCreateTask_ZeroCostAndTrimmedName(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ExistsByNameFunc: func(name string, id int64) (bool, error) {
+			return false, nil
+		},
+		CreateFunc: func(task domain.Task) (domain.Task, error) {
+			return task, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	created, err := service.Create(domain.Task{
+		Name:     "  Free task  ",
+		Cost:     0,
+		Deadline: domain.NewDate(2025, time.August, 10),
+	})
+	if err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if created.Name != "Free task" {
+		t.Errorf("expected trimmed name but got %q", created.Name)
+	}
+}
+
+func TestCreateTask_ValidationReportsEveryField(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{}
+
+	service := NewTaskService(mockRepo)
+
+	_, err := service.Create(domain.Task{
+		Name: "   ",
+		Cost: -1,
+	})
+
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected validation error but got %v", err)
+	}
+
+	codes := make(map[string]string)
+	for _, f := range validationErr.Fields {
+		codes[f.Field] = f.Code
+	}
+	want := map[string]string{"name": "required", "cost": "negative", "deadline": "required"}
+	for field, code := range want {
+		if codes[field] != code {
+			t.Errorf("expected %s to fail with %q but got %q", field, code, codes[field])
+		}
 	}
 }
 
@@ -57,8 +116,10 @@ func TestUpdateTask_Success(t *testing.T) {
 		ExistsByNameFunc: func(name string, id int64) (bool, error) {
 			return false, nil
 		},
-		UpdateFunc: func(id int64, task domain.Task) error {
-			return nil
+		UpdateFunc: func(id int64, task domain.Task) (domain.Task, error) {
+			task.ID = id
+			task.OrderNumber = 3
+			return task, nil
 		},
 	}
 
@@ -66,14 +127,17 @@ func TestUpdateTask_Success(t *testing.T) {
 
 	task := domain.Task{
 		Name:     "Updated Task",
-		Cost:     250,
-		Deadline: "2025-09-01",
+		Cost:     250_00,
+		Deadline: domain.NewDate(2025, time.September, 1),
 	}
 
-	err := service.Update(1, task)
+	updated, err := service.Update(1, task)
 	if err != nil {
 		t.Errorf("expected success but got error: %v", err)
 	}
+	if updated.ID != 1 || updated.OrderNumber != 3 {
+		t.Errorf("expected persisted task but got %+v", updated)
+	}
 }
 
 func TestUpdateTask_DuplicateName(t *testing.T) {
@@ -87,29 +151,114 @@ func TestUpdateTask_DuplicateName(t *testing.T) {
 
 	task := domain.Task{
 		Name:     "Duplicate Name",
-		Cost:     150,
-		Deadline: "2025-09-01",
+		Cost:     150_00,
+		Deadline: domain.NewDate(2025, time.September, 1),
 	}
 
-	err := service.Update(1, task)
-	if err == nil {
-		t.Error("expected duplicate name error but got nil")
+	_, err := service.Update(1, task)
+	if !errors.Is(err, domain.ErrDuplicateName) {
+		t.Errorf("expected duplicate name error but got %v", err)
+	}
+}
+
+func TestPatchTask_ZeroCost(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		PatchFunc: func(id int64, patch domain.TaskPatch) (domain.Task, error) {
+			if patch.Name != nil || patch.Deadline != nil {
+				t.Errorf("expected only cost to be patched but got %+v", patch)
+			}
+			return domain.Task{ID: id, Name: "Task 1", Cost: *patch.Cost}, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	cost := domain.Money(0)
+	patched, err := service.Patch(1, domain.TaskPatch{Cost: &cost})
+	if err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if patched.Cost != 0 {
+		t.Errorf("expected cost 0 but got %s", patched.Cost)
+	}
+}
+
+func TestUpdateTask_PassesExpectedVersion(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ExistsByNameFunc: func(name string, id int64) (bool, error) {
+			return false, nil
+		},
+		UpdateFunc: func(id int64, task domain.Task) (domain.Task, error) {
+			if task.Version != 3 {
+				return domain.Task{}, domain.ErrPreconditionFailed
+			}
+			task.Version++
+			return task, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	updated, err := service.Update(1, domain.Task{
+		Name:     "Task 1",
+		Cost:     100_00,
+		Deadline: domain.NewDate(2025, time.September, 1),
+		Version:  3,
+	})
+	if err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if updated.Version != 4 {
+		t.Errorf("expected version 4 but got %d", updated.Version)
+	}
+}
+
+func TestPatchTask_DuplicateName(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ExistsByNameFunc: func(name string, id int64) (bool, error) {
+			return name == "Taken", nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	name := "  Taken "
+	_, err := service.Patch(1, domain.TaskPatch{Name: &name})
+	if !errors.Is(err, domain.ErrDuplicateName) {
+		t.Errorf("expected duplicate name error but got %v", err)
+	}
+}
+
+func TestPatchTask_ValidatesOnlyChangedFields(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{}
+
+	service := NewTaskService(mockRepo)
+
+	cost := domain.Money(-1)
+	_, err := service.Patch(1, domain.TaskPatch{Cost: &cost})
+
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected validation error but got %v", err)
+	}
+	if len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "cost" {
+		t.Errorf("expected only cost to be reported but got %+v", validationErr.Fields)
 	}
 }
 
 func TestGetAllTasks_Success(t *testing.T) {
 	mockRepo := &mocks.TaskRepositoryMock{
-		ListFunc: func() ([]domain.Task, error) {
+		ListFunc: func(opts domain.ListOptions) ([]domain.Task, int, error) {
 			return []domain.Task{
-				{ID: 1, Name: "Task 1", Cost: 100, Deadline: "2025-08-20", OrderNumber: 1},
-				{ID: 2, Name: "Task 2", Cost: 200, Deadline: "2025-08-25", OrderNumber: 2},
-			}, nil
+				{ID: 1, Name: "Task 1", Cost: 100_00, Deadline: domain.NewDate(2025, time.August, 20), OrderNumber: 1},
+				{ID: 2, Name: "Task 2", Cost: 200_00, Deadline: domain.NewDate(2025, time.August, 25), OrderNumber: 2},
+			}, 2, nil
 		},
 	}
 
 	service := NewTaskService(mockRepo)
 
-	tasks, err := service.List()
+	tasks, _, err := service.List(domain.ListOptions{})
 	if err != nil {
 		t.Errorf("expected success but got error: %v", err)
 	}
@@ -120,29 +269,187 @@ func TestGetAllTasks_Success(t *testing.T) {
 
 func TestGetAllTasks_Error(t *testing.T) {
 	mockRepo := &mocks.TaskRepositoryMock{
-		ListFunc: func() ([]domain.Task, error) {
-			return nil, errors.New("database error")
+		ListFunc: func(opts domain.ListOptions) ([]domain.Task, int, error) {
+			return nil, 0, errors.New("database error")
 		},
 	}
 
 	service := NewTaskService(mockRepo)
 
-	_, err := service.List()
+	_, _, err := service.List(domain.ListOptions{})
 	if err == nil {
 		t.Error("expected error but got nil")
 	}
 }
 
+func TestListTasks_Pagination(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ListFunc: func(opts domain.ListOptions) ([]domain.Task, int, error) {
+			if opts.Offset() != 20 {
+				t.Errorf("expected offset 20 but got %d", opts.Offset())
+			}
+			return []domain.Task{{ID: 21}}, 41, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	_, pagination, err := service.List(domain.ListOptions{Limit: 10, Page: 3, Sort: "cost", Desc: true})
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if pagination.Total != 41 || pagination.TotalPages != 5 || pagination.Page != 3 {
+		t.Errorf("unexpected pagination %+v", pagination)
+	}
+}
+
+func TestListTasks_InvalidOptions(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{}
+
+	service := NewTaskService(mockRepo)
+
+	costMin, costMax := domain.Money(500_00), domain.Money(100_00)
+	_, _, err := service.List(domain.ListOptions{Limit: 1000, Sort: "banana", CostMin: &costMin, CostMax: &costMax})
+
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected validation error but got %v", err)
+	}
+	if len(validationErr.Fields) != 3 {
+		t.Errorf("expected 3 invalid fields but got %+v", validationErr.Fields)
+	}
+}
+
+func TestSearchTasks_DefaultLimit(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		SearchFunc: func(query string, limit int) ([]domain.SearchResult, error) {
+			if query != "relatório" || limit != domain.DefaultSearchLimit {
+				t.Errorf("unexpected search %q with limit %d", query, limit)
+			}
+			return []domain.SearchResult{{Task: domain.Task{ID: 1, Name: "Relatório mensal"}, Snippet: "<mark>Relatório</mark> mensal"}}, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	results, err := service.Search("  relatório ", 0)
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 result but got %d", len(results))
+	}
+}
+
+func TestSearchTasks_EmptyQuery(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{}
+
+	service := NewTaskService(mockRepo)
+
+	_, err := service.Search("   ", 0)
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected validation error but got %v", err)
+	}
+}
+
+func TestGetTaskByID_Success(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		GetByIDFunc: func(id int64) (domain.Task, error) {
+			return domain.Task{ID: id, Name: "Task 1", Cost: 100_00, Deadline: domain.NewDate(2025, time.August, 20), OrderNumber: 1}, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	task, err := service.GetByID(1)
+	if err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if task.ID != 1 {
+		t.Errorf("expected task 1 but got %d", task.ID)
+	}
+}
+
+func TestGetTaskByID_NotFound(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		GetByIDFunc: func(id int64) (domain.Task, error) {
+			return domain.Task{}, domain.ErrNotFound
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	_, err := service.GetByID(999)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected not found error but got %v", err)
+	}
+}
+
+func TestTransitionTask_Allowed(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		GetByIDFunc: func(id int64) (domain.Task, error) {
+			return domain.Task{ID: id, Status: domain.StatusInProgress, Version: 2}, nil
+		},
+		UpdateStatusFunc: func(id int64, from, to domain.Status, version int64) (domain.Task, error) {
+			if from != domain.StatusInProgress || to != domain.StatusDone {
+				t.Errorf("unexpected transition from %s to %s", from, to)
+			}
+			now := time.Now()
+			return domain.Task{ID: id, Status: to, CompletedAt: &now, Version: 3}, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	updated, err := service.Transition(1, domain.StatusDone, 2)
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if updated.Status != domain.StatusDone || updated.CompletedAt == nil {
+		t.Errorf("expected completed task but got %+v", updated)
+	}
+}
+
+func TestTransitionTask_NotAllowed(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		GetByIDFunc: func(id int64) (domain.Task, error) {
+			return domain.Task{ID: id, Status: domain.StatusCancelled}, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	_, err := service.Transition(1, domain.StatusDone, 0)
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("expected conflict error but got %v", err)
+	}
+}
+
+func TestTransitionTask_StaleVersion(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		GetByIDFunc: func(id int64) (domain.Task, error) {
+			return domain.Task{ID: id, Status: domain.StatusTodo, Version: 5}, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	_, err := service.Transition(1, domain.StatusInProgress, 4)
+	if !errors.Is(err, domain.ErrPreconditionFailed) {
+		t.Errorf("expected precondition failed error but got %v", err)
+	}
+}
+
 func TestDeleteTask_Success(t *testing.T) {
 	mockRepo := &mocks.TaskRepositoryMock{
-		DeleteFunc: func(id int64) error {
+		DeleteFunc: func(id int64, version int64) error {
 			return nil
 		},
 	}
 
 	service := NewTaskService(mockRepo)
 
-	err := service.Delete(1)
+	err := service.Delete(1, 0)
 	if err != nil {
 		t.Errorf("expected success but got error: %v", err)
 	}
@@ -150,34 +457,67 @@ func TestDeleteTask_Success(t *testing.T) {
 
 func TestDeleteTask_Error(t *testing.T) {
 	mockRepo := &mocks.TaskRepositoryMock{
-		DeleteFunc: func(id int64) error {
+		DeleteFunc: func(id int64, version int64) error {
 			return errors.New("delete failed")
 		},
 	}
 
 	service := NewTaskService(mockRepo)
 
-	err := service.Delete(1)
+	err := service.Delete(1, 0)
 	if err == nil {
 		t.Error("expected error but got nil")
 	}
 }
 
+func TestDeleteTask_NotFound(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		DeleteFunc: func(id int64, version int64) error {
+			return domain.ErrNotFound
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	err := service.Delete(999, 0)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected not found error but got %v", err)
+	}
+}
+
+func TestDeleteTask_StaleVersion(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		DeleteFunc: func(id int64, version int64) error {
+			if version != 2 {
+				return domain.ErrPreconditionFailed
+			}
+			return nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	err := service.Delete(1, 1)
+	if !errors.Is(err, domain.ErrPreconditionFailed) {
+		t.Errorf("expected precondition failed error but got %v", err)
+	}
+}
+
 func TestReorderTask_Success(t *testing.T) {
 	mockRepo := &mocks.TaskRepositoryMock{
-		ReorderFunc: func(id int64, direction int64) error {
+		ReorderFunc: func(id int64, position int64, version int64) error {
 			return nil
 		},
 	}
 
 	service := NewTaskService(mockRepo)
 
-	err := service.Reorder(1, 1)
+	err := service.Reorder(1, 1, 0)
 	if err != nil {
 		t.Errorf("expected success but got error: %v", err)
 	}
 
-	err = service.Reorder(1, -1)
+	err = service.Reorder(1, -1, 0)
 	if err != nil {
 		t.Errorf("expected success but got error: %v", err)
 	}
@@ -185,15 +525,234 @@ func TestReorderTask_Success(t *testing.T) {
 
 func TestReorderTask_Error(t *testing.T) {
 	mockRepo := &mocks.TaskRepositoryMock{
-		ReorderFunc: func(id int64, direction int64) error {
+		ReorderFunc: func(id int64, position int64, version int64) error {
 			return errors.New("reorder failed")
 		},
 	}
 
 	service := NewTaskService(mockRepo)
 
-	err := service.Reorder(1, 1)
+	err := service.Reorder(1, 1, 0)
 	if err == nil {
 		t.Error("expected error but got nil")
 	}
 }
+
+func TestReorderTask_NotFound(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ReorderFunc: func(id int64, position int64, version int64) error {
+			return domain.ErrNotFound
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	err := service.Reorder(999, 1, 0)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected not found error but got %v", err)
+	}
+}
+
+func TestReorderAllTasks_Success(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ReorderAllFunc: func(ids []int64, version string) ([]domain.Task, string, error) {
+			tasks := make([]domain.Task, len(ids))
+			for i, id := range ids {
+				tasks[i] = domain.Task{ID: id, OrderNumber: i + 1}
+			}
+			return tasks, "v2", nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	tasks, version, err := service.ReorderAll([]int64{3, 1, 2}, "v1")
+	if err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if len(tasks) != 3 || tasks[0].ID != 3 || tasks[0].OrderNumber != 1 {
+		t.Errorf("expected task 3 first but got %+v", tasks)
+	}
+	if version != "v2" {
+		t.Errorf("expected the new list version but got %q", version)
+	}
+}
+
+func TestReorderAllTasks_StaleVersion(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ReorderAllFunc: func(ids []int64, version string) ([]domain.Task, string, error) {
+			if version != "v2" {
+				return nil, "", domain.ErrPreconditionFailed
+			}
+			return nil, version, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	_, _, err := service.ReorderAll([]int64{1, 2}, "v1")
+	if !errors.Is(err, domain.ErrPreconditionFailed) {
+		t.Errorf("expected precondition failed error but got %v", err)
+	}
+}
+
+func TestReorderAllTasks_DuplicateID(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{}
+
+	service := NewTaskService(mockRepo)
+
+	_, _, err := service.ReorderAll([]int64{1, 2, 1}, "")
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected validation error but got %v", err)
+	}
+}
+
+func TestRestoreTask_Success(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		RestoreFunc: func(id int64, version int64) (domain.Task, error) {
+			return domain.Task{ID: id, Name: "Task 1", OrderNumber: 3, Version: version + 1}, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	task, err := service.Restore(1, 2)
+	if err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if task.DeletedAt != nil || task.Version != 3 {
+		t.Errorf("expected restored task at version 3 but got %+v", task)
+	}
+}
+
+func TestRestoreTask_DuplicateName(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		RestoreFunc: func(id int64, version int64) (domain.Task, error) {
+			return domain.Task{}, domain.ErrDuplicateName
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	_, err := service.Restore(1, 0)
+	if !errors.Is(err, domain.ErrDuplicateName) {
+		t.Errorf("expected duplicate name error but got %v", err)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	var cutoff time.Time
+	mockRepo := &mocks.TaskRepositoryMock{
+		PurgeDeletedFunc: func(before time.Time) (int64, error) {
+			cutoff = before
+			return 2, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	purged, err := service.PurgeTrash(24 * time.Hour)
+	if err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("expected 2 purged tasks but got %d", purged)
+	}
+	if age := time.Since(cutoff); age < 24*time.Hour || age > 25*time.Hour {
+		t.Errorf("expected cutoff about a day ago but got %v", cutoff)
+	}
+}
+
+func TestWithAudit_AttributesChanges(t *testing.T) {
+	var recorded domain.AuditInfo
+	scoped := &mocks.TaskRepositoryMock{
+		DeleteFunc: func(id int64, version int64) error {
+			return nil
+		},
+	}
+	mockRepo := &mocks.TaskRepositoryMock{
+		WithAuditFunc: func(info domain.AuditInfo) repository.TaskRepository {
+			recorded = info
+			return scoped
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	info := domain.AuditInfo{Actor: "ana", RequestID: "req-1"}
+	if err := service.WithAudit(info).Delete(1, 0); err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if recorded != info {
+		t.Errorf("expected audit info %+v but got %+v", info, recorded)
+	}
+}
+
+func TestRevertTask_Success(t *testing.T) {
+	snapshot := domain.TaskRevision{TaskID: 1, Revision: 2, Name: "Task 1", Cost: 500_00, Deadline: domain.NewDate(2025, time.August, 10)}
+	mockRepo := &mocks.TaskRepositoryMock{
+		GetRevisionFunc: func(id int64, revision int) (domain.TaskRevision, error) {
+			return snapshot, nil
+		},
+		ExistsByNameFunc: func(name string, id int64) (bool, error) {
+			return false, nil
+		},
+		RevertFunc: func(id int64, revision int, version int64) (domain.Task, error) {
+			return domain.Task{ID: id, Name: snapshot.Name, Cost: snapshot.Cost, Deadline: snapshot.Deadline, Version: 5}, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	task, err := service.Revert(1, 2, 4)
+	if err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if task.Cost != 500_00 {
+		t.Errorf("expected cost 500.00 but got %v", task.Cost)
+	}
+}
+
+func TestRevertTask_DuplicateName(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		GetRevisionFunc: func(id int64, revision int) (domain.TaskRevision, error) {
+			return domain.TaskRevision{TaskID: id, Revision: revision, Name: "Taken"}, nil
+		},
+		ExistsByNameFunc: func(name string, id int64) (bool, error) {
+			return true, nil
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	_, err := service.Revert(1, 1, 0)
+	if !errors.Is(err, domain.ErrDuplicateName) {
+		t.Errorf("expected duplicate name error but got %v", err)
+	}
+}
+
+func TestRevertTask_RevisionNotFound(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		GetRevisionFunc: func(id int64, revision int) (domain.TaskRevision, error) {
+			return domain.TaskRevision{}, domain.ErrRevisionNotFound
+		},
+	}
+
+	service := NewTaskService(mockRepo)
+
+	_, err := service.Revert(1, 9, 0)
+	if !errors.Is(err, domain.ErrRevisionNotFound) {
+		t.Errorf("expected revision not found error but got %v", err)
+	}
+}
+
+func TestRevertTask_InvalidRevision(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{}
+
+	service := NewTaskService(mockRepo)
+
+	_, err := service.Revert(1, 0, 0)
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected validation error but got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunTrashPurger purges tasks older than retention from the trash once at
+// start-up and then every interval, until ctx is cancelled. A zero retention
+// or interval disables purging.
+func RunTrashPurger(ctx context.Context, s *TaskService, retention, interval time.Duration) {
+	if retention <= 0 || interval <= 0 {
+		slog.Info("Trash purge disabled")
+		return
+	}
+
+	slog.Info("Starting trash purger", "retention", retention, "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeTrash(retention); err != nil {
+			slog.Error("Failed to purge trash", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping trash purger")
+			return
+		case <-ticker.C:
+		}
+	}
+}
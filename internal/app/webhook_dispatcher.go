@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunWebhookDispatcher sends due webhook deliveries every interval until ctx
+// is cancelled. A zero interval disables it.
+func RunWebhookDispatcher(ctx context.Context, s *WebhookService, interval time.Duration) {
+	if interval <= 0 {
+		slog.Info("Webhook dispatcher disabled")
+		return
+	}
+
+	slog.Info("Starting webhook dispatcher", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.DeliverDue(ctx); err != nil {
+			slog.Error("Failed to deliver webhooks", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping webhook dispatcher")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+	"strconv"
+	"time"
+)
+
+// maxDrainedResponse caps how much of a receiver's response body is read
+// so the connection can be reused. The body itself is not kept.
+const maxDrainedResponse = 4096
+
+// Headers sent with every webhook delivery.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookEventIDHeader   = "X-Webhook-Event-ID"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookOptions configures how deliveries are sent and retried. Failed
+// deliveries are retried after Backoff, doubled after every failure up to
+// MaxBackoff, until MaxAttempts have been made.
+type WebhookOptions struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Timeout     time.Duration
+	// BatchSize is how many due deliveries DeliverDue claims at once.
+	BatchSize int
+	// AllowedNetworks lists internal networks deliveries may still be sent
+	// to, such as a receiver on the same host. See targetAllowed.
+	AllowedNetworks []netip.Prefix
+}
+
+// WebhookService manages webhook subscriptions and sends the deliveries
+// queued for them.
+type WebhookService struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	opts   WebhookOptions
+}
+
+func NewWebhookService(repo repository.WebhookRepository, opts WebhookOptions) *WebhookService {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	return &WebhookService{repo: repo, client: newWebhookClient(opts.Timeout, opts.AllowedNetworks), opts: opts}
+}
+
+// Create saves a subscription. Without a secret one is generated; the
+// returned subscription is the only place it is shown.
+func (s *WebhookService) Create(webhook domain.Webhook) (domain.Webhook, error) {
+	webhook.Normalize()
+	if err := webhook.Validate(); err != nil {
+		return domain.Webhook{}, err
+	}
+	if err := validateTarget(webhook.URL, s.opts.AllowedNetworks); err != nil {
+		return domain.Webhook{}, err
+	}
+	if webhook.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return domain.Webhook{}, err
+		}
+		webhook.Secret = secret
+	}
+	return s.repo.Create(webhook)
+}
+
+func (s *WebhookService) List() ([]domain.Webhook, error) {
+	webhooks, err := s.repo.List()
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, err
+}
+
+func (s *WebhookService) GetByID(id int64) (domain.Webhook, error) {
+	webhook, err := s.repo.GetByID(id)
+	webhook.Secret = ""
+	return webhook, err
+}
+
+// Update replaces a subscription. An empty secret keeps the current one.
+func (s *WebhookService) Update(id int64, webhook domain.Webhook) (domain.Webhook, error) {
+	webhook.Normalize()
+	if err := webhook.Validate(); err != nil {
+		return domain.Webhook{}, err
+	}
+	if err := validateTarget(webhook.URL, s.opts.AllowedNetworks); err != nil {
+		return domain.Webhook{}, err
+	}
+	updated, err := s.repo.Update(id, webhook)
+	updated.Secret = ""
+	return updated, err
+}
+
+func (s *WebhookService) Delete(id int64) error {
+	return s.repo.Delete(id)
+}
+
+// Deliveries returns a page of the deliveries made to a subscription, newest
+// first. A limit of 0 uses domain.DefaultDeliveryLimit.
+func (s *WebhookService) Deliveries(webhookID int64, opts domain.ListOptions) ([]domain.WebhookDelivery, domain.Pagination, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, domain.Pagination{}, err
+	}
+	if opts.Limit == 0 {
+		opts.Limit = domain.DefaultDeliveryLimit
+	}
+
+	deliveries, total, err := s.repo.ListDeliveries(webhookID, opts)
+	if err != nil {
+		return nil, domain.Pagination{}, err
+	}
+	return deliveries, domain.NewPagination(opts, total), nil
+}
+
+// Delivery returns a delivery with the log of its attempts.
+func (s *WebhookService) Delivery(webhookID, id int64) (domain.WebhookDelivery, error) {
+	return s.repo.GetDelivery(webhookID, id)
+}
+
+// Redeliver queues the event of a delivery to be sent again right away, as a
+// new delivery with the same event ID.
+func (s *WebhookService) Redeliver(webhookID, id int64) (domain.WebhookDelivery, error) {
+	return s.repo.Redeliver(webhookID, id)
+}
+
+// Enqueue queues a change from the outbox for every subscription asking for
+// it. An event already queued is not queued again.
+func (s *WebhookService) Enqueue(event domain.OutboxEvent) error {
+	payload := domain.WebhookPayload{
+		ID:        event.Change.EventID,
+		Event:     domain.WebhookEventPrefix + string(event.Change.Type),
+		CreatedAt: event.CreatedAt.UTC(),
+		Data:      event.Change,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.Enqueue(payload.ID, payload.Event, body)
+	return err
+}
+
+// DeliverDue sends the deliveries whose next attempt is due, one batch at a
+// time, until none is left. It returns how many were attempted.
+func (s *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	sent := 0
+	for ctx.Err() == nil {
+		// The lease outlasts a send so no other dispatcher picks the
+		// delivery up while it is in flight.
+		deliveries, err := s.repo.ClaimDue(s.opts.BatchSize, s.opts.Timeout+time.Minute)
+		if err != nil {
+			return sent, err
+		}
+		for _, d := range deliveries {
+			if err := s.deliver(ctx, d); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		if len(deliveries) < s.opts.BatchSize {
+			break
+		}
+	}
+	return sent, nil
+}
+
+// deliver makes one attempt at sending d and records its outcome.
+func (s *WebhookService) deliver(ctx context.Context, d domain.WebhookDelivery) error {
+	attempt := s.send(ctx, d)
+	attempt.Attempt = d.Attempts + 1
+
+	status := domain.DeliveryPending
+	var next *time.Time
+	switch {
+	case attempt.Succeeded():
+		status = domain.DeliverySucceeded
+	case attempt.Attempt >= s.opts.MaxAttempts:
+		status = domain.DeliveryFailed
+	default:
+		at := attempt.AttemptedAt.Add(domain.WebhookBackoff(attempt.Attempt, s.opts.Backoff, s.opts.MaxBackoff))
+		next = &at
+	}
+
+	if status != domain.DeliverySucceeded {
+		slog.Warn("Webhook delivery failed", "id", d.ID, "attempt", attempt.Attempt, "status_code", attempt.StatusCode, "error", attempt.Error)
+	}
+	return s.repo.RecordAttempt(d.ID, attempt, status, next)
+}
+
+// send posts the signed payload of d to its subscription.
+func (s *WebhookService) send(ctx context.Context, d domain.WebhookDelivery) (attempt domain.DeliveryAttempt) {
+	start := time.Now()
+	attempt.AttemptedAt = start.UTC()
+	defer func() { attempt.DurationMS = time.Since(start).Milliseconds() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "prova-fattocs-webhooks")
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookEventIDHeader, d.EventID)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(start.Unix(), 10))
+	req.Header.Set(WebhookSignatureHeader, domain.SignWebhook(d.Secret, start, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedResponse))
+	if !attempt.Succeeded() {
+		attempt.Error = fmt.Sprintf("receiver responded %s", resp.Status)
+	}
+	return attempt
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/mocks"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookService_Create(t *testing.T) {
+	mockRepo := &mocks.WebhookRepositoryMock{
+		CreateFunc: func(webhook domain.Webhook) (domain.Webhook, error) {
+			webhook.ID = 1
+			return webhook, nil
+		},
+	}
+	service := NewWebhookService(mockRepo, WebhookOptions{})
+
+	created, err := service.Create(domain.Webhook{URL: " https://erp.example.com/hooks ", Events: []string{"task.created", "task.created"}, Active: true})
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if created.URL != "https://erp.example.com/hooks" || len(created.Events) != 1 {
+		t.Errorf("expected the subscription to be normalized but got %+v", created)
+	}
+	if len(created.Secret) < domain.MinWebhookSecretLength {
+		t.Errorf("expected a generated secret but got %q", created.Secret)
+	}
+
+	_, err = service.Create(domain.Webhook{URL: "ftp://example.com", Events: []string{"task.exploded"}, Secret: "short"})
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 3 {
+		t.Errorf("expected url, events and secret to be invalid but got %v", err)
+	}
+}
+
+func TestWebhookService_Enqueue(t *testing.T) {
+	var gotID, gotEvent string
+	var gotPayload domain.WebhookPayload
+	mockRepo := &mocks.WebhookRepositoryMock{
+		EnqueueFunc: func(eventID, event string, payload []byte) (int, error) {
+			gotID, gotEvent = eventID, event
+			return 1, json.Unmarshal(payload, &gotPayload)
+		},
+	}
+	service := NewWebhookService(mockRepo, WebhookOptions{})
+
+	event := domain.OutboxEvent{
+		ID:        3,
+		Change:    domain.ChangeEvent{EventID: "0b7e5f0e-42", Type: domain.ChangeDeleted, TaskID: 7},
+		CreatedAt: time.Now(),
+	}
+	if err := service.Enqueue(event); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if gotID != "0b7e5f0e-42" || gotEvent != "task.deleted" {
+		t.Errorf("expected event 0b7e5f0e-42 task.deleted but got %s %s", gotID, gotEvent)
+	}
+	if gotPayload.ID != "0b7e5f0e-42" || gotPayload.Data.TaskID != 7 {
+		t.Errorf("expected the change in the payload but got %+v", gotPayload)
+	}
+}
+
+// queueMock holds a single delivery in memory and records its attempts, as
+// the Postgres queue would.
+type queueMock struct {
+	delivery domain.WebhookDelivery
+	attempts []domain.DeliveryAttempt
+	status   domain.DeliveryStatus
+	next     *time.Time
+}
+
+func (q *queueMock) repo() *mocks.WebhookRepositoryMock {
+	return &mocks.WebhookRepositoryMock{
+		ClaimDueFunc: func(limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+			if q.status != domain.DeliveryPending {
+				return nil, nil
+			}
+			// Retries are made due straight away so the test does not wait.
+			q.status = "claimed"
+			return []domain.WebhookDelivery{q.delivery}, nil
+		},
+		RecordAttemptFunc: func(id int64, attempt domain.DeliveryAttempt, status domain.DeliveryStatus, next *time.Time) error {
+			q.attempts = append(q.attempts, attempt)
+			q.delivery.Attempts = attempt.Attempt
+			q.status, q.next = status, next
+			return nil
+		},
+	}
+}
+
+// loopback allows deliveries to httptest receivers.
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+func TestWebhookService_DeliverDue_SignsPayload(t *testing.T) {
+	const secret = "0123456789abcdef"
+	payload := []byte(`{"id":"42","event":"task.created"}`)
+
+	var verified bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		unix, _ := strconv.ParseInt(r.Header.Get(WebhookTimestampHeader), 10, 64)
+		want := domain.SignWebhook(secret, time.Unix(unix, 0), body)
+		verified = r.Header.Get(WebhookSignatureHeader) == want &&
+			r.Header.Get(WebhookEventHeader) == "task.created" &&
+			r.Header.Get(WebhookEventIDHeader) == "42"
+		w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	queue := &queueMock{
+		delivery: domain.WebhookDelivery{ID: 9, EventID: "42", Event: "task.created", Payload: payload, URL: receiver.URL, Secret: secret},
+		status:   domain.DeliveryPending,
+	}
+	service := NewWebhookService(queue.repo(), WebhookOptions{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute, Timeout: time.Second, AllowedNetworks: loopback})
+
+	sent, err := service.DeliverDue(context.Background())
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if sent != 1 || !verified {
+		t.Errorf("expected one delivery with a valid signature but sent %d (verified %v)", sent, verified)
+	}
+	if queue.status != domain.DeliverySucceeded || queue.next != nil {
+		t.Errorf("expected the delivery to succeed but got %s", queue.status)
+	}
+	if len(queue.attempts) != 1 || queue.attempts[0].StatusCode != http.StatusOK || queue.attempts[0].Error != "" {
+		t.Errorf("expected the attempt to be logged but got %+v", queue.attempts)
+	}
+}
+
+func TestWebhookService_DeliverDue_RetriesWithBackoff(t *testing.T) {
+	calls := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	queue := &queueMock{
+		delivery: domain.WebhookDelivery{ID: 9, Payload: []byte(`{}`), URL: receiver.URL, Secret: "0123456789abcdef"},
+		status:   domain.DeliveryPending,
+	}
+	service := NewWebhookService(queue.repo(), WebhookOptions{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute, Timeout: time.Second, AllowedNetworks: loopback})
+
+	var waits []time.Duration
+	for queue.status == domain.DeliveryPending {
+		if _, err := service.DeliverDue(context.Background()); err != nil {
+			t.Fatalf("expected success but got error: %v", err)
+		}
+		if queue.next != nil {
+			last := queue.attempts[len(queue.attempts)-1]
+			waits = append(waits, queue.next.Sub(last.AttemptedAt))
+		}
+	}
+
+	if calls != 3 || queue.status != domain.DeliveryFailed {
+		t.Errorf("expected 3 attempts before failing but got %d (status %s)", calls, queue.status)
+	}
+	if len(waits) != 2 || waits[0] != time.Second || waits[1] != 2*time.Second {
+		t.Errorf("expected waits of 1s and 2s but got %v", waits)
+	}
+	if last := queue.attempts[len(queue.attempts)-1]; last.StatusCode != http.StatusServiceUnavailable || last.Error == "" {
+		t.Errorf("expected the failure to be logged but got %+v", last)
+	}
+}
+
+func TestWebhookService_RefusesInternalTargets(t *testing.T) {
+	mockRepo := &mocks.WebhookRepositoryMock{
+		CreateFunc: func(webhook domain.Webhook) (domain.Webhook, error) { return webhook, nil },
+	}
+	service := NewWebhookService(mockRepo, WebhookOptions{})
+
+	for _, url := range []string{"http://169.254.169.254/latest/meta-data", "http://127.0.0.1:8080/hooks", "http://[::1]/hooks", "http://localhost/hooks", "https://10.0.0.7/hooks"} {
+		_, err := service.Create(domain.Webhook{URL: url})
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Fields[0].Code != "forbidden_target" {
+			t.Errorf("expected %s to be refused but got %v", url, err)
+		}
+	}
+	if _, err := NewWebhookService(mockRepo, WebhookOptions{AllowedNetworks: loopback}).Create(domain.Webhook{URL: "http://127.0.0.1:8080/hooks"}); err != nil {
+		t.Errorf("expected an allowed network to be accepted but got %v", err)
+	}
+}
+
+func TestWebhookService_DeliverDue_RefusesInternalAddresses(t *testing.T) {
+	called := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	// Saved subscriptions may point anywhere their host name resolves to, so
+	// the address is checked again when connecting.
+	queue := &queueMock{
+		delivery: domain.WebhookDelivery{ID: 9, Payload: []byte(`{}`), URL: receiver.URL, Secret: "0123456789abcdef"},
+		status:   domain.DeliveryPending,
+	}
+	service := NewWebhookService(queue.repo(), WebhookOptions{MaxAttempts: 1, Timeout: time.Second})
+
+	if _, err := service.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if called || queue.status != domain.DeliveryFailed {
+		t.Errorf("expected the delivery to fail without connecting but got %s", queue.status)
+	}
+	if last := queue.attempts[len(queue.attempts)-1]; !strings.Contains(last.Error, errForbiddenTarget.Error()) {
+		t.Errorf("expected the refusal to be logged but got %+v", last)
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"prova-fattocs/internal/domain"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errForbiddenTarget is returned when a delivery would connect to an address
+// outside the public internet.
+var errForbiddenTarget = errors.New("webhook target address is not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range, internal like the
+// private ranges but not reported by netip.Addr.IsPrivate.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// targetAllowed reports whether deliveries may connect to addr. Loopback,
+// private, link-local, multicast and unspecified addresses are refused unless
+// they fall inside one of the allowed networks.
+func targetAllowed(addr netip.Addr, allowed []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, network := range allowed {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsUnspecified() &&
+		!addr.IsLinkLocalUnicast() && !addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() && !addr.IsMulticast() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// newWebhookClient returns the client deliveries are sent with. Its dialer
+// checks every address a host name resolves to, including after redirects,
+// so a subscription cannot reach internal services through DNS. Proxies are
+// not used, since they would connect on the client's behalf unchecked.
+func newWebhookClient(timeout time.Duration, allowed []netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !targetAllowed(addrPort.Addr(), allowed) {
+				return fmt.Errorf("%w: %s", errForbiddenTarget, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// validateTarget rejects URLs that name an internal address directly, so the
+// mistake is reported when saving instead of on every delivery. Host names
+// are only checked when deliveries connect.
+func validateTarget(rawURL string, allowed []netip.Prefix) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	host := u.Hostname()
+	addr, err := netip.ParseAddr(host)
+	if strings.EqualFold(host, "localhost") {
+		addr, err = netip.IPv6Loopback(), nil
+	}
+	if err != nil || targetAllowed(addr, allowed) {
+		return nil
+	}
+	return domain.NewValidationError(domain.FieldError{
+		Field:   "url",
+		Code:    "forbidden_target",
+		Message: "url must not point to a loopback, private or link-local address",
+	})
+}
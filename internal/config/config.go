@@ -1,7 +1,12 @@
 package config
 
 import (
+	"log"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -11,16 +16,63 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	ServerPort string
+
+	// TrashRetention is how long deleted tasks stay restorable before they
+	// are purged; TrashPurgeInterval is how often the purge runs.
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	// OutboxPollInterval is how often committed changes are published from
+	// the outbox to live clients and webhooks. Published changes are kept
+	// for OutboxRetention; OutboxPurgeInterval is how often the purge runs.
+	OutboxPollInterval  time.Duration
+	OutboxRetention     time.Duration
+	OutboxPurgeInterval time.Duration
+
+	// EventBufferSize is how many task changes are kept for live clients
+	// resuming with Last-Event-ID.
+	EventBufferSize int
+
+	// LockTTL is how long an edit lock taken on /ws lasts unless renewed.
+	// Locks are kept in memory, so they only hold within one instance.
+	LockTTL time.Duration
+
+	// Webhook deliveries are retried after WebhookBackoff, doubled after each
+	// failure up to WebhookMaxBackoff, until WebhookMaxAttempts were made.
+	WebhookMaxAttempts  int
+	WebhookBackoff      time.Duration
+	WebhookMaxBackoff   time.Duration
+	WebhookTimeout      time.Duration
+	WebhookPollInterval time.Duration
+
+	// WebhookAllowedNetworks are internal networks, refused by default, that
+	// webhooks may be delivered to anyway.
+	WebhookAllowedNetworks []netip.Prefix
 }
 
 func Load() *Config {
 	return &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "postgres"),
-		DBName:     getEnv("DB_NAME", "todo"),
-		ServerPort: getEnv("SERVER_PORT", "8080"),
+		DBHost:              getEnv("DB_HOST", "localhost"),
+		DBPort:              getEnv("DB_PORT", "5432"),
+		DBUser:              getEnv("DB_USER", "postgres"),
+		DBPassword:          getEnv("DB_PASSWORD", "postgres"),
+		DBName:              getEnv("DB_NAME", "todo"),
+		ServerPort:          getEnv("SERVER_PORT", "8080"),
+		TrashRetention:      getDuration("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval:  getDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		OutboxPollInterval:  getDuration("OUTBOX_POLL_INTERVAL", 500*time.Millisecond),
+		OutboxRetention:     getDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+		OutboxPurgeInterval: getDuration("OUTBOX_PURGE_INTERVAL", time.Hour),
+		EventBufferSize:     getInt("EVENT_BUFFER_SIZE", 1000),
+		LockTTL:             getDuration("LOCK_TTL", 30*time.Second),
+
+		WebhookMaxAttempts:  getInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookBackoff:      getDuration("WEBHOOK_BACKOFF", 30*time.Second),
+		WebhookMaxBackoff:   getDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+		WebhookTimeout:      getDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookPollInterval: getDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+
+		WebhookAllowedNetworks: getPrefixes("WEBHOOK_ALLOWED_NETWORKS"),
 	}
 }
 
@@ -30,3 +82,46 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getDuration reads a Go duration such as "720h" or "15m".
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("invalid duration for %s: %v", key, err)
+	}
+	return d
+}
+
+func getInt(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("invalid integer for %s: %v", key, err)
+	}
+	return n
+}
+
+// getPrefixes reads a comma-separated list of CIDR networks such as
+// "127.0.0.0/8,10.1.0.0/16".
+func getPrefixes(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			log.Fatalf("invalid network for %s: %v", key, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
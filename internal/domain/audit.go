@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultAuditLimit is how many events a page of the audit log holds when no
+// limit is given.
+const DefaultAuditLimit = 100
+
+// SystemActor is recorded for changes made by the server itself, such as the
+// trash purge.
+const SystemActor = "system"
+
+// EventAction names the kind of change a TaskEvent records.
+type EventAction string
+
+const (
+	ActionCreated       EventAction = "created"
+	ActionUpdated       EventAction = "updated"
+	ActionStatusChanged EventAction = "status_changed"
+	ActionReordered     EventAction = "reordered"
+	ActionDeleted       EventAction = "deleted"
+	ActionRestored      EventAction = "restored"
+	ActionReverted      EventAction = "reverted"
+	ActionPurged        EventAction = "purged"
+)
+
+// AuditInfo identifies who made a change and the request that carried it.
+type AuditInfo struct {
+	Actor     string
+	RequestID string
+}
+
+// TaskEvent is an entry of the audit log. Before is nil for created tasks and
+// After is nil for purged ones.
+type TaskEvent struct {
+	ID        int64       `json:"id"`
+	TaskID    int64       `json:"task_id"`
+	Action    EventAction `json:"action"`
+	Before    *Task       `json:"before"`
+	After     *Task       `json:"after"`
+	Actor     string      `json:"actor"`
+	RequestID string      `json:"request_id"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// AuditFilter narrows and paginates the audit log, newest events first. Zero
+// values mean "no filter"; From is inclusive and To exclusive.
+type AuditFilter struct {
+	TaskID int64
+	From   *time.Time
+	To     *time.Time
+	Actor  string
+
+	Limit int
+	Page  int
+}
+
+// Offset is the number of events to skip to reach the current page.
+func (f AuditFilter) Offset() int {
+	return ListOptions{Limit: f.Limit, Page: f.Page}.Offset()
+}
+
+func (f AuditFilter) Validate() error {
+	var fields []FieldError
+	if f.Limit < 0 || f.Limit > MaxPageSize {
+		fields = append(fields, FieldError{
+			Field:   "limit",
+			Code:    "out_of_range",
+			Message: fmt.Sprintf("limit must not be negative or greater than %d", MaxPageSize),
+		})
+	}
+	if f.Page < 0 {
+		fields = append(fields, FieldError{Field: "page", Code: "out_of_range", Message: "page must not be negative"})
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		fields = append(fields, FieldError{Field: "from", Code: "invalid_range", Message: "from must be before to"})
+	}
+
+	if len(fields) > 0 {
+		return NewValidationError(fields...)
+	}
+	return nil
+}
+
+// Pagination describes where the filtered page sits among total events.
+func (f AuditFilter) Pagination(total int) Pagination {
+	return NewPagination(ListOptions{Limit: f.Limit, Page: f.Page}, total)
+}
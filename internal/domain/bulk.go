@@ -0,0 +1,34 @@
+package domain
+
+// MaxBulkOperations caps how many operations a single bulk request may hold.
+const MaxBulkOperations = 500
+
+// BulkAction is the kind of change a BulkOperation makes.
+type BulkAction string
+
+const (
+	BulkCreate BulkAction = "create"
+	BulkUpdate BulkAction = "update"
+	BulkDelete BulkAction = "delete"
+)
+
+// BulkOperation is one item of a bulk request. Task carries the fields of a
+// create or update; ID and Version select the task an update or delete
+// applies to. Err is set when the item could not be parsed; it is reported as
+// the item's result without being applied.
+type BulkOperation struct {
+	Action  BulkAction
+	ID      int64
+	Version int64
+	Task    Task
+	Err     error
+}
+
+// BulkResult is the outcome of one BulkOperation. Task is the saved task of a
+// successful create or update.
+type BulkResult struct {
+	Action BulkAction
+	ID     int64
+	Task   *Task
+	Err    error
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// ChangeType names the kind of change a ChangeEvent announces.
+type ChangeType string
+
+const (
+	ChangeCreated   ChangeType = "created"
+	ChangeUpdated   ChangeType = "updated"
+	ChangeDeleted   ChangeType = "deleted"
+	ChangeReordered ChangeType = "reordered"
+)
+
+// ChangeEvent announces a committed change to live clients. Created and
+// updated events carry the saved task, deleted events its ID, and reordered
+// events the IDs of every task in their new order. ID is the change's
+// position in the outbox, increasing in commit order; EventID identifies the
+// change itself, so consumers can discard one they receive twice.
+type ChangeEvent struct {
+	ID      uint64     `json:"-"`
+	EventID string     `json:"event_id,omitempty"`
+	Type    ChangeType `json:"type"`
+	TaskID  int64      `json:"task_id,omitempty"`
+	Task    *Task      `json:"task,omitempty"`
+	Order   []int64    `json:"order,omitempty"`
+}
+
+// OutboxEvent is a change read back from the outbox, where it was written in
+// the same transaction as the change itself. Events are handed out in the
+// order they were committed; ID is that position.
+type OutboxEvent struct {
+	ID        int64
+	Change    ChangeEvent
+	CreatedAt time.Time
+}
@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+// TaskLock is a soft lock on a task while a user edits it. It expires unless
+// the holder renews it. Token is only given to the holder, which sends it
+// back in X-Lock-Token when writing the task.
+type TaskLock struct {
+	TaskID    int64     `json:"task_id"`
+	User      string    `json:"user"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Token     string    `json:"token,omitempty"`
+}
+
+// LockedError reports that a task is locked by another session. It matches
+// ErrLocked through errors.Is.
+type LockedError struct {
+	Lock TaskLock
+}
+
+func (e *LockedError) Error() string {
+	return ErrLocked.Error() + ": " + e.Lock.User
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// PresenceUser is a user connected to the collaboration channel and the
+// tasks they currently hold locks on.
+type PresenceUser struct {
+	User     string  `json:"user"`
+	Sessions int     `json:"sessions"`
+	Editing  []int64 `json:"editing"`
+}
+
+// CollabMessageType names the kind of a CollabMessage.
+type CollabMessageType string
+
+const (
+	// Sent by clients.
+	CollabLock   CollabMessageType = "lock"
+	CollabUnlock CollabMessageType = "unlock"
+	CollabPing   CollabMessageType = "ping"
+
+	// Sent by the server.
+	CollabPresence   CollabMessageType = "presence"
+	CollabLocked     CollabMessageType = "locked"
+	CollabUnlocked   CollabMessageType = "unlocked"
+	CollabLockDenied CollabMessageType = "lock_denied"
+	CollabChange     CollabMessageType = "change"
+	CollabPong       CollabMessageType = "pong"
+	CollabError      CollabMessageType = "error"
+)
+
+// CollabMessage is a message of the collaboration channel, in either
+// direction. Only the fields relevant to its type are set.
+type CollabMessage struct {
+	Type    CollabMessageType `json:"type"`
+	TaskID  int64             `json:"task_id,omitempty"`
+	Lock    *TaskLock         `json:"lock,omitempty"`
+	Users   []PresenceUser    `json:"users,omitempty"`
+	Change  *ChangeEvent      `json:"change,omitempty"`
+	Message string            `json:"message,omitempty"`
+}
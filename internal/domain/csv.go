@@ -0,0 +1,77 @@
+package domain
+
+import "strings"
+
+// MaxImportRows caps how many tasks a single CSV import may hold.
+const MaxImportRows = 5000
+
+// CSVFormat selects the field delimiter and decimal separator of a CSV file,
+// e.g. ';' and ',' for spreadsheets in a Brazilian locale.
+type CSVFormat struct {
+	Delimiter rune
+	Decimal   rune
+}
+
+// DefaultCSVFormat is plain RFC 4180 CSV with a decimal point.
+var DefaultCSVFormat = CSVFormat{Delimiter: ',', Decimal: '.'}
+
+func (f CSVFormat) Validate() error {
+	var fields []FieldError
+	if !strings.ContainsRune(",;\t|", f.Delimiter) {
+		fields = append(fields, FieldError{
+			Field:   "delimiter",
+			Code:    "invalid_value",
+			Message: "delimiter must be one of comma, semicolon, pipe or tab",
+		})
+	}
+	if f.Decimal != '.' && f.Decimal != ',' {
+		fields = append(fields, FieldError{Field: "decimal", Code: "invalid_value", Message: "decimal must be point or comma"})
+	} else if f.Decimal == f.Delimiter {
+		fields = append(fields, FieldError{Field: "decimal", Code: "invalid_value", Message: "decimal must differ from delimiter"})
+	}
+
+	if len(fields) > 0 {
+		return NewValidationError(fields...)
+	}
+	return nil
+}
+
+// FormatMoney writes m with the format's decimal separator and no grouping.
+func (f CSVFormat) FormatMoney(m Money) string {
+	if f.Decimal == '.' {
+		return m.String()
+	}
+	return strings.Replace(m.String(), ".", string(f.Decimal), 1)
+}
+
+// ParseMoney reads an amount written with the format's decimal separator.
+// Grouping separators are rejected rather than guessed at, so "1.500" is
+// never silently read as fifteen hundred.
+func (f CSVFormat) ParseMoney(s string) (Money, error) {
+	if f.Decimal != '.' {
+		if strings.Contains(s, ".") {
+			return 0, ErrMoneyFormat
+		}
+		s = strings.Replace(s, string(f.Decimal), ".", 1)
+	}
+	return ParseMoney(s)
+}
+
+// ImportAction is what a CSV import did with a row.
+type ImportAction string
+
+const (
+	ImportCreated   ImportAction = "created"
+	ImportUpdated   ImportAction = "updated"
+	ImportUnchanged ImportAction = "unchanged"
+)
+
+// ImportRowResult is the outcome of one CSV row. Row is the line the row
+// starts on, counting the header as line 1.
+type ImportRowResult struct {
+	Row    int
+	Name   string
+	Action ImportAction
+	Task   *Task
+	Err    error
+}
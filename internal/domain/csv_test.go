@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCSVFormat_Money(t *testing.T) {
+	brazilian := CSVFormat{Delimiter: ';', Decimal: ','}
+
+	if got := brazilian.FormatMoney(1234_05); got != "1234,05" {
+		t.Errorf("expected 1234,05 but got %s", got)
+	}
+
+	cases := []struct {
+		input string
+		want  Money
+		err   error
+	}{
+		{"1234,05", 1234_05, nil},
+		{"7", 7_00, nil},
+		{"1.500", 0, ErrMoneyFormat},
+		{"1.500,00", 0, ErrMoneyFormat},
+		{"1,005", 0, ErrMoneyPrecision},
+	}
+	for _, tc := range cases {
+		got, err := brazilian.ParseMoney(tc.input)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("ParseMoney(%q): expected error %v but got %v", tc.input, tc.err, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseMoney(%q): expected %d but got %d", tc.input, tc.want, got)
+		}
+	}
+}
+
+func TestCSVFormat_Validate(t *testing.T) {
+	if err := (CSVFormat{Delimiter: ';', Decimal: ','}).Validate(); err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+	if err := (CSVFormat{Delimiter: ',', Decimal: ','}).Validate(); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected validation error but got %v", err)
+	}
+	if err := (CSVFormat{Delimiter: 'x', Decimal: '.'}).Validate(); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected validation error but got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the only format accepted and produced for calendar dates.
+const DateLayout = "2006-01-02"
+
+// Date is a calendar day without time of day or time zone. It is encoded as
+// YYYY-MM-DD in JSON and maps onto a Postgres DATE column.
+type Date struct {
+	t time.Time
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{t: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses s strictly as YYYY-MM-DD.
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q: expected format YYYY-MM-DD", s)
+	}
+	return Date{t: t}, nil
+}
+
+// Today returns the current date in UTC.
+func Today() Date {
+	now := time.Now().UTC()
+	return NewDate(now.Year(), now.Month(), now.Day())
+}
+
+func (d Date) Time() time.Time {
+	return d.t
+}
+
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+func (d Date) Before(other Date) bool {
+	return d.t.Before(other.t)
+}
+
+func (d Date) After(other Date) bool {
+	return d.t.After(other.t)
+}
+
+func (d Date) String() string {
+	return d.t.Format(DateLayout)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	parsed, err := ParseDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner. lib/pq hands DATE columns over as time.Time.
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d = NewDate(v.Year(), v.Month(), v.Day())
+		return nil
+	case string:
+		return d.UnmarshalText([]byte(v[:min(len(v), len(DateLayout))]))
+	case []byte:
+		return d.UnmarshalText(v[:min(len(v), len(DateLayout))])
+	default:
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+}
+
+// Value implements driver.Valuer.
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDate_Strict(t *testing.T) {
+	for _, input := range []string{"banana", "2025-8-10", "10/08/2025", "2025-02-30", "2025-08-10T00:00:00Z", ""} {
+		if _, err := ParseDate(input); err == nil {
+			t.Errorf("expected %q to be rejected", input)
+		}
+	}
+
+	d, err := ParseDate("2025-08-10")
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if d != NewDate(2025, time.August, 10) {
+		t.Errorf("expected 2025-08-10 but got %s", d)
+	}
+}
+
+func TestDate_JSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Deadline Date `json:"deadline"`
+	}{NewDate(2025, time.August, 10)})
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if string(data) != `{"deadline":"2025-08-10"}` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+
+	var out struct {
+		Deadline Date `json:"deadline"`
+	}
+	if err := json.Unmarshal([]byte(`{"deadline":"banana"}`), &out); err == nil {
+		t.Error("expected invalid date to fail decoding")
+	}
+}
+
+func TestDate_Scan(t *testing.T) {
+	var d Date
+	if err := d.Scan(time.Date(2025, time.August, 10, 0, 0, 0, 0, time.FixedZone("BRT", -3*3600))); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if d.String() != "2025-08-10" {
+		t.Errorf("expected 2025-08-10 but got %s", d)
+	}
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotFound      = errors.New("task not found")
+	ErrDuplicateName = errors.New("task with this name already exists")
+	ErrValidation    = errors.New("validation failed")
+	ErrConflict      = errors.New("conflict with the current state of the task")
+
+	ErrPreconditionFailed = errors.New("task was modified since it was last read")
+	ErrRevisionNotFound   = errors.New("revision not found")
+	ErrBulkAborted        = errors.New("not applied because another operation in the batch failed")
+	ErrLocked             = errors.New("task is being edited by another user")
+
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+)
+
+// FieldError describes a single invalid input field. Code is a stable,
+// machine-readable identifier clients can map onto their own messages.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError groups every FieldError found in a request. It matches
+// ErrValidation through errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return ErrValidation.Error()
+	}
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MaxPageSize caps how many tasks a single page may hold.
+const MaxPageSize = 500
+
+// SortFields lists the fields tasks can be sorted by.
+var SortFields = []string{"order", "name", "cost", "deadline"}
+
+// ListOptions narrows, sorts and paginates a task listing. Zero values mean
+// "no filter"; a Limit of 0 returns every matching task.
+type ListOptions struct {
+	Limit int
+	Page  int
+
+	// Sort is one of SortFields; Desc reverses it. Defaults to "order".
+	Sort string
+	Desc bool
+
+	CostMin        *Money
+	CostMax        *Money
+	DeadlineBefore *Date
+	DeadlineAfter  *Date
+	NameContains   string
+	Statuses       []Status
+}
+
+// Offset is the number of tasks to skip to reach the current page. The
+// database still reads the skipped rows, so walks over every task should use
+// keyset reads (TaskRepository.ListAfter) instead of deep pages.
+func (o ListOptions) Offset() int {
+	if o.Limit == 0 || o.Page <= 1 {
+		return 0
+	}
+	return (o.Page - 1) * o.Limit
+}
+
+func (o ListOptions) Validate() error {
+	var fields []FieldError
+	if o.Limit < 0 || o.Limit > MaxPageSize {
+		fields = append(fields, FieldError{
+			Field:   "limit",
+			Code:    "out_of_range",
+			Message: fmt.Sprintf("limit must not be negative or greater than %d", MaxPageSize),
+		})
+	}
+	if o.Page < 0 {
+		fields = append(fields, FieldError{Field: "page", Code: "out_of_range", Message: "page must not be negative"})
+	}
+	if o.Sort != "" && !slices.Contains(SortFields, o.Sort) {
+		fields = append(fields, FieldError{
+			Field:   "sort",
+			Code:    "invalid_value",
+			Message: "sort must be one of " + strings.Join(SortFields, ", "),
+		})
+	}
+	if o.CostMin != nil && o.CostMax != nil && *o.CostMin > *o.CostMax {
+		fields = append(fields, FieldError{Field: "cost_min", Code: "invalid_range", Message: "cost_min must not exceed cost_max"})
+	}
+	if o.DeadlineAfter != nil && o.DeadlineBefore != nil && !o.DeadlineAfter.Before(*o.DeadlineBefore) {
+		fields = append(fields, FieldError{Field: "deadline_after", Code: "invalid_range", Message: "deadline_after must be before deadline_before"})
+	}
+
+	if len(fields) > 0 {
+		return NewValidationError(fields...)
+	}
+	return nil
+}
+
+// Pagination describes where a page sits within the full result set.
+type Pagination struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// NewPagination describes the page opts selects out of total results. An
+// empty result has no pages; without a limit every result is on one page.
+func NewPagination(opts ListOptions, total int) Pagination {
+	p := Pagination{Page: max(opts.Page, 1), Limit: opts.Limit, Total: total}
+	switch {
+	case total == 0:
+	case opts.Limit > 0:
+		p.TotalPages = (total + opts.Limit - 1) / opts.Limit
+	default:
+		p.TotalPages = 1
+	}
+	return p
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "testing"
+
+func TestNewPagination(t *testing.T) {
+	cases := []struct {
+		name  string
+		opts  ListOptions
+		total int
+		want  Pagination
+	}{
+		{"empty", ListOptions{Limit: 10}, 0, Pagination{Page: 1, Limit: 10}},
+		{"empty without limit", ListOptions{}, 0, Pagination{Page: 1}},
+		{"empty past the end", ListOptions{Limit: 10, Page: 3}, 0, Pagination{Page: 3, Limit: 10}},
+		{"without limit", ListOptions{}, 7, Pagination{Page: 1, Total: 7, TotalPages: 1}},
+		{"exact pages", ListOptions{Limit: 5, Page: 2}, 10, Pagination{Page: 2, Limit: 5, Total: 10, TotalPages: 2}},
+		{"partial last page", ListOptions{Limit: 5}, 11, Pagination{Page: 1, Limit: 5, Total: 11, TotalPages: 3}},
+	}
+
+	for _, tc := range cases {
+		if got := NewPagination(tc.opts, tc.total); got != tc.want {
+			t.Errorf("%s: expected %+v but got %+v", tc.name, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxMoney is the largest amount a NUMERIC(10, 2) column can hold.
+const MaxMoney Money = 99_999_999_99
+
+var (
+	ErrMoneyFormat    = errors.New("amount must be a decimal number")
+	ErrMoneyPrecision = errors.New("amount must have at most 2 decimal places")
+	ErrMoneyNegative  = errors.New("amount must not be negative")
+	ErrMoneyRange     = errors.New("amount must not exceed 99999999.99")
+)
+
+// Money is an exact amount stored as an integer number of cents. It is
+// encoded as a JSON number with two decimal places and maps onto a Postgres
+// NUMERIC(10, 2) column.
+type Money int64
+
+// ParseMoney parses a plain decimal such as "1234.5" or "-0.99" without going
+// through float64. Digits past the second decimal place must be zero.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	units, fraction, _ := strings.Cut(s, ".")
+	if units == "" || !isDigits(units) || !isDigits(fraction) {
+		return 0, ErrMoneyFormat
+	}
+	if len(strings.TrimRight(fraction, "0")) > 2 {
+		return 0, ErrMoneyPrecision
+	}
+	fraction = (fraction + "00")[:2]
+
+	units = strings.TrimLeft(units, "0")
+	if len(units) > 16 {
+		return 0, ErrMoneyRange
+	}
+	cents, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return 0, ErrMoneyFormat
+	}
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+// Validate reports whether m fits the cost column: non-negative and no more
+// than MaxMoney.
+func (m Money) Validate() error {
+	if m < 0 {
+		return ErrMoneyNegative
+	}
+	if m > MaxMoney {
+		return ErrMoneyRange
+	}
+	return nil
+}
+
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts both JSON numbers and numeric strings.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseMoney(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// Scan implements sql.Scanner. lib/pq hands NUMERIC columns over as []byte.
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*m = Money(v * 100)
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into Money: %w", s, err)
+	}
+	*m = parsed
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
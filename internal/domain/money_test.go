@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		input string
+		want  Money
+		err   error
+	}{
+		{"0", 0, nil},
+		{"1234.5", 1234_50, nil},
+		{"0.99", 99, nil},
+		{"10.500", 10_50, nil},
+		{"99999999.99", MaxMoney, nil},
+		{"-3.20", -3_20, nil},
+		{"1.005", 0, ErrMoneyPrecision},
+		{"1e3", 0, ErrMoneyFormat},
+		{"banana", 0, ErrMoneyFormat},
+		{".5", 0, ErrMoneyFormat},
+		{"12345678901234567890", 0, ErrMoneyRange},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseMoney(tc.input)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("ParseMoney(%q): expected error %v but got %v", tc.input, tc.err, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseMoney(%q): expected %d but got %d", tc.input, tc.want, got)
+		}
+	}
+}
+
+func TestMoney_Validate(t *testing.T) {
+	if err := Money(-1).Validate(); !errors.Is(err, ErrMoneyNegative) {
+		t.Errorf("expected negative error but got %v", err)
+	}
+	if err := (MaxMoney + 1).Validate(); !errors.Is(err, ErrMoneyRange) {
+		t.Errorf("expected range error but got %v", err)
+	}
+	if err := MaxMoney.Validate(); err != nil {
+		t.Errorf("expected success but got error: %v", err)
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Cost Money `json:"cost"`
+	}{1234_05})
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if string(data) != `{"cost":1234.05}` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+
+	var out struct {
+		Cost Money `json:"cost"`
+	}
+	if err := json.Unmarshal([]byte(`{"cost":0.1}`), &out); err != nil || out.Cost != 10 {
+		t.Errorf("expected 10 cents but got %d (error %v)", out.Cost, err)
+	}
+}
+
+func TestMoney_Scan(t *testing.T) {
+	var m Money
+	if err := m.Scan([]byte("250.75")); err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+	if m != 250_75 {
+		t.Errorf("expected 25075 cents but got %d", m)
+	}
+}
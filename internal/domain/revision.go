@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// TaskRevision is a snapshot of the editable fields of a task as saved by a
+// create, update, patch or revert. Revisions are numbered from 1 per task.
+type TaskRevision struct {
+	TaskID    int64     `json:"task_id"`
+	Revision  int       `json:"revision"`
+	Name      string    `json:"name"`
+	Cost      Money     `json:"cost"`
+	Deadline  Date      `json:"deadline"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package domain
+
+// DefaultSearchLimit is how many results a search returns when no limit is
+// given.
+const DefaultSearchLimit = 20
+
+// SearchResult is a task matched by a full-text search. Snippet is the task
+// name, HTML-escaped, with the matched words wrapped in <mark> tags.
+type SearchResult struct {
+	Task
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Status is the workflow state of a task.
+type Status string
+
+const (
+	StatusTodo       Status = "todo"
+	StatusInProgress Status = "in_progress"
+	StatusDone       Status = "done"
+	StatusCancelled  Status = "cancelled"
+)
+
+// Statuses lists every valid Status.
+var Statuses = []Status{StatusTodo, StatusInProgress, StatusDone, StatusCancelled}
+
+// transitions lists, for each status, the statuses a task may move to next.
+// Finished tasks can be reopened; cancelled ones only go back to the backlog.
+var transitions = map[Status][]Status{
+	StatusTodo:       {StatusInProgress, StatusDone, StatusCancelled},
+	StatusInProgress: {StatusTodo, StatusDone, StatusCancelled},
+	StatusDone:       {StatusTodo, StatusInProgress},
+	StatusCancelled:  {StatusTodo},
+}
+
+func ParseStatus(s string) (Status, error) {
+	status := Status(s)
+	if !status.Valid() {
+		return "", fmt.Errorf("invalid status %q: expected one of todo, in_progress, done, cancelled", s)
+	}
+	return status, nil
+}
+
+func (s Status) Valid() bool {
+	return slices.Contains(Statuses, s)
+}
+
+func (s Status) CanTransitionTo(next Status) bool {
+	return slices.Contains(transitions[s], next)
+}
@@ -1,9 +1,140 @@
 package domain
 
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxNameLength matches the VARCHAR(255) name column.
+const MaxNameLength = 255
+
+var (
+	minDeadline = NewDate(1900, 1, 1)
+	maxDeadline = NewDate(9999, 12, 31)
+)
+
+// Task is a single to-do item. Version increases on every write to the task
+// itself and backs optimistic concurrency control: writes carrying a Version
+// only succeed while it still matches the stored one. Its place in the
+// presentation order is not part of the version, so reordering does not
+// invalidate anyone's edits. CompletedAt is set while the task
+// is done and DeletedAt while it sits in the trash.
 type Task struct {
-	ID          int64   `json:"id"`
-	Name        string  `json:"name"`
-	Cost        float64 `json:"cost"`
-	Deadline    string  `json:"deadline"`
-	OrderNumber int     `json:"order_number"`
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Cost        Money      `json:"cost"`
+	Deadline    Date       `json:"deadline"`
+	OrderNumber int        `json:"order_number"`
+	Status      Status     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Version     int64      `json:"version"`
+}
+
+// Normalize trims surrounding whitespace from the task name.
+func (t *Task) Normalize() {
+	t.Name = strings.TrimSpace(t.Name)
+}
+
+// Validate checks every user-editable field and reports all problems at once
+// as a *ValidationError. Call Normalize first.
+func (t Task) Validate() error {
+	var fields []FieldError
+	if f, ok := validateName(t.Name); !ok {
+		fields = append(fields, f)
+	}
+	if err := t.Cost.Validate(); err != nil {
+		fields = append(fields, MoneyFieldError("cost", err))
+	}
+	if f, ok := validateDeadline(t.Deadline); !ok {
+		fields = append(fields, f)
+	}
+
+	if len(fields) > 0 {
+		return NewValidationError(fields...)
+	}
+	return nil
+}
+
+// TaskPatch holds the fields of a partial update. A nil field is left
+// untouched, so an explicit zero such as a cost of 0 can still be applied.
+// Version, when non-zero, is the version the caller expects to overwrite.
+type TaskPatch struct {
+	Name     *string
+	Cost     *Money
+	Deadline *Date
+	Version  int64
+}
+
+func (p TaskPatch) IsEmpty() bool {
+	return p.Name == nil && p.Cost == nil && p.Deadline == nil
+}
+
+// Normalize trims surrounding whitespace from the patched name, if any.
+func (p *TaskPatch) Normalize() {
+	if p.Name != nil {
+		name := strings.TrimSpace(*p.Name)
+		p.Name = &name
+	}
+}
+
+// Validate checks only the fields present in the patch.
+func (p TaskPatch) Validate() error {
+	var fields []FieldError
+	if p.Name != nil {
+		if f, ok := validateName(*p.Name); !ok {
+			fields = append(fields, f)
+		}
+	}
+	if p.Cost != nil {
+		if err := p.Cost.Validate(); err != nil {
+			fields = append(fields, MoneyFieldError("cost", err))
+		}
+	}
+	if p.Deadline != nil {
+		if f, ok := validateDeadline(*p.Deadline); !ok {
+			fields = append(fields, f)
+		}
+	}
+
+	if len(fields) > 0 {
+		return NewValidationError(fields...)
+	}
+	return nil
+}
+
+// MoneyFieldError describes a ParseMoney or Money.Validate failure for field.
+func MoneyFieldError(field string, err error) FieldError {
+	code := "invalid_format"
+	switch {
+	case errors.Is(err, ErrMoneyPrecision):
+		code = "too_many_decimals"
+	case errors.Is(err, ErrMoneyNegative):
+		code = "negative"
+	case errors.Is(err, ErrMoneyRange):
+		code = "out_of_range"
+	}
+	return FieldError{Field: field, Code: code, Message: err.Error()}
+}
+
+func validateName(name string) (FieldError, bool) {
+	if name == "" {
+		return FieldError{Field: "name", Code: "required", Message: "name is required"}, false
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return FieldError{Field: "name", Code: "too_long", Message: "name must be at most 255 characters"}, false
+	}
+	return FieldError{}, true
+}
+
+func validateDeadline(deadline Date) (FieldError, bool) {
+	if deadline.IsZero() {
+		return FieldError{Field: "deadline", Code: "required", Message: "deadline is required"}, false
+	}
+	if deadline.Before(minDeadline) || deadline.After(maxDeadline) {
+		return FieldError{Field: "deadline", Code: "out_of_range", Message: "deadline must be between 1900-01-01 and 9999-12-31"}, false
+	}
+	return FieldError{}, true
 }
@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinWebhookSecretLength is the shortest secret a subscription may be given.
+const MinWebhookSecretLength = 16
+
+// WebhookEventPrefix prefixes change types to name webhook events, as in
+// "task.created".
+const WebhookEventPrefix = "task."
+
+// WebhookEvents lists the event types a subscription may ask for.
+var WebhookEvents = []string{
+	WebhookEventPrefix + string(ChangeCreated),
+	WebhookEventPrefix + string(ChangeUpdated),
+	WebhookEventPrefix + string(ChangeDeleted),
+	WebhookEventPrefix + string(ChangeReordered),
+}
+
+// Webhook is a subscription to task events. An empty Events receives every
+// event. Secret is only returned to clients when it is set.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Normalize trims the URL and removes repeated event types.
+func (w *Webhook) Normalize() {
+	w.URL = strings.TrimSpace(w.URL)
+	seen := make(map[string]bool, len(w.Events))
+	events := make([]string, 0, len(w.Events))
+	for _, e := range w.Events {
+		e = strings.TrimSpace(e)
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	w.Events = events
+}
+
+// Validate reports every invalid field of the subscription. An empty secret
+// is accepted; the service generates one.
+func (w Webhook) Validate() error {
+	var fields []FieldError
+
+	if w.URL == "" {
+		fields = append(fields, FieldError{Field: "url", Code: "required", Message: "url is required"})
+	} else if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fields = append(fields, FieldError{Field: "url", Code: "invalid_format", Message: "url must be an absolute http or https URL"})
+	}
+
+	for _, e := range w.Events {
+		if !isWebhookEvent(e) {
+			fields = append(fields, FieldError{
+				Field:   "events",
+				Code:    "invalid_value",
+				Message: fmt.Sprintf("unknown event %q, expected one of %s", e, strings.Join(WebhookEvents, ", ")),
+			})
+		}
+	}
+
+	if w.Secret != "" && len(w.Secret) < MinWebhookSecretLength {
+		fields = append(fields, FieldError{
+			Field:   "secret",
+			Code:    "too_short",
+			Message: fmt.Sprintf("secret must be at least %d characters long", MinWebhookSecretLength),
+		})
+	}
+
+	if len(fields) > 0 {
+		return NewValidationError(fields...)
+	}
+	return nil
+}
+
+func isWebhookEvent(e string) bool {
+	for _, known := range WebhookEvents {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the state of a webhook delivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their next attempt.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded deliveries got a 2xx response.
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed deliveries ran out of attempts.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event queued for one subscription. EventID is the
+// same for every delivery of an event, including redeliveries, so receivers
+// can discard duplicates.
+type WebhookDelivery struct {
+	ID            int64             `json:"id"`
+	WebhookID     int64             `json:"webhook_id"`
+	EventID       string            `json:"event_id"`
+	Event         string            `json:"event"`
+	Payload       json.RawMessage   `json:"payload" swaggertype:"object"`
+	Status        DeliveryStatus    `json:"status"`
+	Attempts      int               `json:"attempts"`
+	NextAttemptAt *time.Time        `json:"next_attempt_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	AttemptLog    []DeliveryAttempt `json:"attempt_log,omitempty"`
+
+	// URL and Secret are those of the subscription, filled in for sending.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// DeliveryAttempt records one try at sending a delivery. StatusCode is 0
+// when no response was received.
+type DeliveryAttempt struct {
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"status_code"`
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// Succeeded reports whether the receiver accepted the delivery.
+func (a DeliveryAttempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// SignWebhook returns the value of the signature header for a delivery: the
+// hex HMAC-SHA256, keyed by secret, of the timestamp, a dot and the body.
+// Receivers recompute it to check the payload and reject stale timestamps
+// to stop replays.
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookBackoff returns how long to wait after the given number of failed
+// attempts: base doubled after every failure, never more than max.
+func WebhookBackoff(attempts int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
+
+// DefaultDeliveryLimit is how many deliveries a page holds when no limit is
+// given.
+const DefaultDeliveryLimit = 100
+
+// WebhookPayload is the body sent to subscribers. ID identifies the event
+// and is the same across retries and redeliveries.
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      ChangeEvent `json:"data"`
+}
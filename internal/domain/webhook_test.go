@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := WebhookBackoff(tt.attempts, 30*time.Second, 10*time.Minute); got != tt.want {
+			t.Errorf("WebhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	at := time.Unix(1754000000, 0)
+	body := []byte(`{"id":"1"}`)
+
+	sig := SignWebhook("0123456789abcdef", at, body)
+	if sig != SignWebhook("0123456789abcdef", at, body) {
+		t.Error("expected the signature to be deterministic")
+	}
+	if len(sig) != len("sha256=")+64 || sig[:7] != "sha256=" {
+		t.Errorf("expected a hex SHA-256 signature but got %q", sig)
+	}
+	if sig == SignWebhook("0123456789abcdeX", at, body) || sig == SignWebhook("0123456789abcdef", at.Add(time.Second), body) {
+		t.Error("expected the signature to depend on the secret and timestamp")
+	}
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"strings"
+	"time"
+
+	"prova-fattocs/internal/domain"
+)
+
+// AuditQuery holds the query string accepted by GET /audit and
+// GET /tasks/:id/history. from and to are RFC 3339 timestamps.
+type AuditQuery struct {
+	From  string `form:"from" example:"2025-08-01T00:00:00Z"`
+	To    string `form:"to" example:"2025-09-01T00:00:00Z"`
+	Actor string `form:"actor"`
+	Limit int    `form:"limit"`
+	Page  int    `form:"page"`
+}
+
+func (q AuditQuery) ToFilter() (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{
+		Actor: strings.TrimSpace(q.Actor),
+		Limit: q.Limit,
+		Page:  q.Page,
+	}
+
+	var fields []domain.FieldError
+	parseTime := func(field, value string) *time.Time {
+		if value == "" {
+			return nil
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			fields = append(fields, domain.FieldError{
+				Field:   field,
+				Code:    "invalid_format",
+				Message: field + " must be an RFC 3339 timestamp such as 2025-08-01T00:00:00Z",
+			})
+			return nil
+		}
+		return &parsed
+	}
+
+	filter.From = parseTime("from", q.From)
+	filter.To = parseTime("to", q.To)
+
+	if len(fields) > 0 {
+		return domain.AuditFilter{}, domain.NewValidationError(fields...)
+	}
+	return filter, nil
+}
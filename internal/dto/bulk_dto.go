@@ -0,0 +1,71 @@
+package dto
+
+import "prova-fattocs/internal/domain"
+
+// Bulk modes accepted by POST /tasks/bulk.
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+)
+
+// BulkTasksDTO is the payload of POST /tasks/bulk. Mode defaults to atomic.
+type BulkTasksDTO struct {
+	Mode       string             `json:"mode" example:"atomic" enums:"atomic,best_effort"`
+	Operations []BulkOperationDTO `json:"operations" binding:"required"`
+}
+
+// BulkOperationDTO is a single create, update or delete. Task is required for
+// creates and updates; ID for updates and deletes. Version plays the role of
+// If-Match for the task it applies to.
+type BulkOperationDTO struct {
+	Action  string         `json:"action" example:"create" enums:"create,update,delete"`
+	ID      int64          `json:"id,omitempty"`
+	Version int64          `json:"version,omitempty"`
+	Task    *CreateTaskDTO `json:"task,omitempty"`
+}
+
+// Atomic reports whether the batch runs all-or-nothing.
+func (d BulkTasksDTO) Atomic() (bool, error) {
+	switch d.Mode {
+	case "", BulkModeAtomic:
+		return true, nil
+	case BulkModeBestEffort:
+		return false, nil
+	}
+	return false, domain.NewValidationError(domain.FieldError{
+		Field:   "mode",
+		Code:    "invalid_value",
+		Message: "mode must be one of atomic, best_effort",
+	})
+}
+
+// ToOperations converts every item, leaving parse errors on the item they
+// belong to so they are reported per item.
+func (d BulkTasksDTO) ToOperations() []domain.BulkOperation {
+	ops := make([]domain.BulkOperation, len(d.Operations))
+	for i, item := range d.Operations {
+		op := domain.BulkOperation{Action: domain.BulkAction(item.Action), ID: item.ID, Version: item.Version}
+		if op.Action == domain.BulkCreate || op.Action == domain.BulkUpdate {
+			if item.Task == nil {
+				op.Err = domain.NewValidationError(domain.FieldError{Field: "task", Code: "required", Message: "task is required"})
+			} else {
+				op.Task, op.Err = item.Task.ToTask()
+			}
+		}
+		ops[i] = op
+	}
+	return ops
+}
+
+// BulkResultDTO reports the outcome of one operation, at the same index as in
+// the request. Status is the HTTP status the operation would have answered
+// on its own.
+type BulkResultDTO struct {
+	Index   int          `json:"index"`
+	Action  string       `json:"action"`
+	ID      int64        `json:"id,omitempty"`
+	Status  int          `json:"status"`
+	Message string       `json:"message,omitempty"`
+	Errors  interface{}  `json:"errors,omitempty"`
+	Task    *domain.Task `json:"task,omitempty"`
+}
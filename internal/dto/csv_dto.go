@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"prova-fattocs/internal/domain"
+)
+
+// CSVFormatQuery holds the query parameters selecting a CSV dialect. Both
+// accept either the character itself, percent-encoded, or its name: ";" has
+// to be sent as %3B or "semicolon" since it is not valid in a query string.
+type CSVFormatQuery struct {
+	Delimiter string `form:"delimiter" example:"semicolon"`
+	Decimal   string `form:"decimal" example:"comma"`
+}
+
+// separatorNames are the names accepted in place of a delimiter or decimal
+// separator.
+var separatorNames = map[string]rune{
+	"comma":     ',',
+	"semicolon": ';',
+	"tab":       '\t',
+	"pipe":      '|',
+	"point":     '.',
+	"dot":       '.',
+}
+
+// ToFormat fills unset parameters from domain.DefaultCSVFormat. The result
+// still has to pass domain.CSVFormat.Validate.
+func (q CSVFormatQuery) ToFormat() domain.CSVFormat {
+	format := domain.DefaultCSVFormat
+	if q.Delimiter != "" {
+		format.Delimiter = separator(q.Delimiter)
+	}
+	if q.Decimal != "" {
+		format.Decimal = separator(q.Decimal)
+	}
+	return format
+}
+
+// separator reads a separator given by name or as a single character;
+// anything else yields utf8.RuneError.
+func separator(s string) rune {
+	if r, ok := separatorNames[strings.ToLower(s)]; ok {
+		return r
+	}
+	return singleRune(s)
+}
+
+// singleRune returns the only rune of s, or utf8.RuneError when s holds more
+// than one.
+func singleRune(s string) rune {
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) {
+		return utf8.RuneError
+	}
+	return r
+}
+
+// ExportTasksQuery holds the query string accepted by GET /tasks/export.csv:
+// the filters and sort of GET /tasks plus the CSV dialect.
+type ExportTasksQuery struct {
+	ListTasksQuery
+	CSVFormatQuery
+}
+
+// ImportRowDTO reports the outcome of one CSV row. Status is the HTTP status
+// the row would have answered as a single request.
+type ImportRowDTO struct {
+	Row     int          `json:"row"`
+	Name    string       `json:"name"`
+	Action  string       `json:"action,omitempty"`
+	Status  int          `json:"status"`
+	Message string       `json:"message,omitempty"`
+	Errors  interface{}  `json:"errors,omitempty"`
+	Task    *domain.Task `json:"task,omitempty"`
+}
+
+// ImportReportDTO sums up a CSV import.
+type ImportReportDTO struct {
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Unchanged int            `json:"unchanged"`
+	Failed    int            `json:"failed"`
+	Rows      []ImportRowDTO `json:"rows"`
+}
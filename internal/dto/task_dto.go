@@ -1,17 +1,213 @@
 package dto
 
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"prova-fattocs/internal/domain"
+)
+
+// CreateTaskDTO carries no binding rules: ToTask and domain.Task.Validate
+// report every invalid field together so clients can highlight them at once.
 type CreateTaskDTO struct {
-	Name     string  `json:"name" binding:"required"`
-	Cost     float64 `json:"cost" binding:"required"`
-	Deadline string  `json:"deadline" binding:"required"`
+	Name     string      `json:"name" example:"Comprar materiais"`
+	Cost     json.Number `json:"cost" swaggertype:"number" example:"1250.50"`
+	Deadline string      `json:"deadline" example:"2025-08-10"`
+}
+
+func (d CreateTaskDTO) ToTask() (domain.Task, error) {
+	return newTask(d.Name, d.Cost, d.Deadline)
 }
 
 type UpdateTaskDTO struct {
-	Name     string  `json:"name" binding:"required"`
-	Cost     float64 `json:"cost" binding:"required"`
-	Deadline string  `json:"deadline" binding:"required"`
+	Name     string      `json:"name" example:"Comprar materiais"`
+	Cost     json.Number `json:"cost" swaggertype:"number" example:"1250.50"`
+	Deadline string      `json:"deadline" example:"2025-08-10"`
+}
+
+func (d UpdateTaskDTO) ToTask() (domain.Task, error) {
+	return newTask(d.Name, d.Cost, d.Deadline)
+}
+
+// PatchTaskDTO accepts any subset of the task fields. Fields left out of the
+// payload (or sent as null) keep their current value.
+type PatchTaskDTO struct {
+	Name     *string      `json:"name,omitempty" example:"Comprar materiais"`
+	Cost     *json.Number `json:"cost,omitempty" swaggertype:"number" example:"1250.50"`
+	Deadline *string      `json:"deadline,omitempty" example:"2025-08-10"`
+}
+
+func (d PatchTaskDTO) ToPatch() (domain.TaskPatch, error) {
+	patch := domain.TaskPatch{Name: d.Name}
+
+	var fields []domain.FieldError
+	if d.Cost != nil {
+		parsed, err := domain.ParseMoney(d.Cost.String())
+		if err != nil {
+			fields = append(fields, domain.MoneyFieldError("cost", err))
+		} else {
+			patch.Cost = &parsed
+		}
+	}
+	if d.Deadline != nil {
+		parsed, err := domain.ParseDate(*d.Deadline)
+		if err != nil {
+			fields = append(fields, domain.FieldError{Field: "deadline", Code: "invalid_format", Message: err.Error()})
+		} else {
+			patch.Deadline = &parsed
+		}
+	}
+
+	if len(fields) > 0 {
+		return domain.TaskPatch{}, domain.NewValidationError(fields...)
+	}
+	return patch, nil
+}
+
+// ListTasksQuery holds the query string accepted by GET /tasks.
+type ListTasksQuery struct {
+	Limit          int    `form:"limit"`
+	Page           int    `form:"page"`
+	Sort           string `form:"sort" example:"-cost"`
+	CostMin        string `form:"cost_min"`
+	CostMax        string `form:"cost_max"`
+	DeadlineBefore string `form:"deadline_before"`
+	DeadlineAfter  string `form:"deadline_after"`
+	NameContains   string `form:"name_contains"`
+	// Status accepts repeated parameters and comma-separated lists.
+	Status []string `form:"status" example:"todo,in_progress"`
+}
+
+// ToOptions parses the query into domain.ListOptions. A leading "-" on sort
+// selects descending order.
+func (q ListTasksQuery) ToOptions() (domain.ListOptions, error) {
+	opts := domain.ListOptions{
+		Limit:        q.Limit,
+		Page:         q.Page,
+		Sort:         strings.TrimPrefix(q.Sort, "-"),
+		Desc:         strings.HasPrefix(q.Sort, "-"),
+		NameContains: strings.TrimSpace(q.NameContains),
+	}
+
+	var fields []domain.FieldError
+	parseMoney := func(field, value string) *domain.Money {
+		if value == "" {
+			return nil
+		}
+		parsed, err := domain.ParseMoney(value)
+		if err != nil {
+			fields = append(fields, domain.MoneyFieldError(field, err))
+			return nil
+		}
+		return &parsed
+	}
+	parseDate := func(field, value string) *domain.Date {
+		if value == "" {
+			return nil
+		}
+		parsed, err := domain.ParseDate(value)
+		if err != nil {
+			fields = append(fields, domain.FieldError{Field: field, Code: "invalid_format", Message: err.Error()})
+			return nil
+		}
+		return &parsed
+	}
+
+	opts.CostMin = parseMoney("cost_min", q.CostMin)
+	opts.CostMax = parseMoney("cost_max", q.CostMax)
+	opts.DeadlineBefore = parseDate("deadline_before", q.DeadlineBefore)
+	opts.DeadlineAfter = parseDate("deadline_after", q.DeadlineAfter)
+
+	for _, value := range q.Status {
+		for _, s := range strings.Split(value, ",") {
+			status, err := domain.ParseStatus(strings.TrimSpace(s))
+			if err != nil {
+				fields = append(fields, domain.FieldError{Field: "status", Code: "invalid_value", Message: err.Error()})
+				continue
+			}
+			opts.Statuses = append(opts.Statuses, status)
+		}
+	}
+
+	if len(fields) > 0 {
+		return domain.ListOptions{}, domain.NewValidationError(fields...)
+	}
+	return opts, nil
+}
+
+// SearchTasksQuery holds the query string accepted by GET /tasks/search.
+type SearchTasksQuery struct {
+	Q     string `form:"q" example:"relatorio financeiro"`
+	Limit int    `form:"limit"`
+}
+
+// RevertTaskQuery holds the query string accepted by POST /tasks/:id/revert.
+type RevertTaskQuery struct {
+	Revision int `form:"revision" example:"3"`
+}
+
+type TransitionTaskDTO struct {
+	Status string `json:"status" binding:"required" example:"in_progress"`
+}
+
+func (d TransitionTaskDTO) ToStatus() (domain.Status, error) {
+	status, err := domain.ParseStatus(d.Status)
+	if err != nil {
+		return "", domain.NewValidationError(domain.FieldError{Field: "status", Code: "invalid_value", Message: err.Error()})
+	}
+	return status, nil
 }
 
 type ReorderTaskDTO struct {
-	Order int64 `json:"order" binding:"required"`
+	Order int64 `json:"order" binding:"required,min=1"`
+}
+
+type ReorderTasksDTO struct {
+	IDs []int64 `json:"ids" binding:"required,min=1"`
+}
+
+// newTask parses the raw fields into a normalized domain.Task. When a field
+// cannot be parsed, the returned *domain.ValidationError also lists what
+// Validate finds wrong with the remaining fields.
+func newTask(name string, cost json.Number, deadline string) (domain.Task, error) {
+	task := domain.Task{Name: name}
+	task.Normalize()
+
+	var fields []domain.FieldError
+	failed := make(map[string]bool)
+
+	if cost == "" {
+		fields = append(fields, domain.FieldError{Field: "cost", Code: "required", Message: "cost is required"})
+		failed["cost"] = true
+	} else if parsed, err := domain.ParseMoney(cost.String()); err != nil {
+		fields = append(fields, domain.MoneyFieldError("cost", err))
+		failed["cost"] = true
+	} else {
+		task.Cost = parsed
+	}
+
+	if deadline == "" {
+		fields = append(fields, domain.FieldError{Field: "deadline", Code: "required", Message: "deadline is required"})
+		failed["deadline"] = true
+	} else if parsed, err := domain.ParseDate(deadline); err != nil {
+		fields = append(fields, domain.FieldError{Field: "deadline", Code: "invalid_format", Message: err.Error()})
+		failed["deadline"] = true
+	} else {
+		task.Deadline = parsed
+	}
+
+	if len(fields) == 0 {
+		return task, nil
+	}
+
+	var validationErr *domain.ValidationError
+	if errors.As(task.Validate(), &validationErr) {
+		for _, f := range validationErr.Fields {
+			if !failed[f.Field] {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return domain.Task{}, domain.NewValidationError(fields...)
 }
@@ -0,0 +1,32 @@
+package dto
+
+import "prova-fattocs/internal/domain"
+
+// WebhookDTO is the payload of POST /webhooks and PUT /webhooks/:id. An empty
+// events list subscribes to every event; active defaults to true. Without a
+// secret, creating generates one and updating keeps the current one.
+type WebhookDTO struct {
+	URL    string   `json:"url" example:"https://erp.example.com/hooks/tasks"`
+	Events []string `json:"events" example:"task.created,task.updated"`
+	Secret string   `json:"secret,omitempty" example:"3b1f0c7e9a8d4e2f"`
+	Active *bool    `json:"active,omitempty" example:"true"`
+}
+
+func (d WebhookDTO) ToWebhook() domain.Webhook {
+	webhook := domain.Webhook{URL: d.URL, Events: d.Events, Secret: d.Secret, Active: true}
+	if d.Active != nil {
+		webhook.Active = *d.Active
+	}
+	return webhook
+}
+
+// DeliveriesQuery holds the query string accepted by
+// GET /webhooks/:id/deliveries.
+type DeliveriesQuery struct {
+	Limit int `form:"limit"`
+	Page  int `form:"page"`
+}
+
+func (q DeliveriesQuery) ToListOptions() domain.ListOptions {
+	return domain.ListOptions{Limit: q.Limit, Page: q.Page}
+}
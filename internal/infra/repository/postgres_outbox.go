@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"prova-fattocs/internal/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Advisory lock keys. outboxWriteLock is held by task writes from the moment
+// they write to the outbox until they commit; see flushOutbox.
+// outboxDispatchLock lets a single dispatcher run at a time across processes.
+const (
+	outboxWriteLock    int64 = 0x6f7574626f78
+	outboxDispatchLock int64 = 0x6f7574626f79
+)
+
+// OutboxRepository reads the outbox written by TaskRepository in the same
+// transaction as each change. Outbox ids increase in commit order.
+type OutboxRepository interface {
+	// After returns the events following id, oldest first, up to limit.
+	After(id int64, limit int) ([]domain.OutboxEvent, error)
+	// LastID returns the id of the newest event, or 0 if there is none.
+	LastID() (int64, error)
+
+	// Dispatch hands the oldest unpublished events, up to limit, to publish
+	// in order, marking each one published once publish returns nil. It
+	// stops at the first error so no event overtakes one that failed, and
+	// returns how many were published. An event is published again if the
+	// process dies before marking it, so consumers must discard duplicates
+	// by EventID. While another process is dispatching it does nothing.
+	Dispatch(limit int, publish func(domain.OutboxEvent) error) (int, error)
+	// PurgePublished deletes the events published before the given time and
+	// returns how many were deleted.
+	PurgePublished(before time.Time) (int64, error)
+}
+
+type PostgresOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresOutboxRepository(db *sql.DB) OutboxRepository {
+	slog.Info("Creating new PostgresOutboxRepository")
+	return &PostgresOutboxRepository{db: db}
+}
+
+func (r *PostgresOutboxRepository) After(id int64, limit int) ([]domain.OutboxEvent, error) {
+	return queryOutbox(r.db, "WHERE id > $1 ORDER BY id LIMIT $2", id, limit)
+}
+
+func (r *PostgresOutboxRepository) LastID() (int64, error) {
+	var id int64
+	if err := r.db.QueryRow("SELECT COALESCE(max(id), 0) FROM outbox").Scan(&id); err != nil {
+		slog.Error("Failed to query last outbox id", "error", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *PostgresOutboxRepository) Dispatch(limit int, publish func(domain.OutboxEvent) error) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Failed to begin transaction", "error", err)
+		return 0, err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.Error("Failed to roll back transaction", "error", err)
+		}
+	}()
+
+	var locked bool
+	if err := tx.QueryRow("SELECT pg_try_advisory_xact_lock($1)", outboxDispatchLock).Scan(&locked); err != nil {
+		slog.Error("Failed to lock outbox dispatch", "error", err)
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	events, err := queryOutbox(tx, "WHERE published_at IS NULL ORDER BY id LIMIT $1", limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var published []int64
+	var publishErr error
+	for _, event := range events {
+		if publishErr = publish(event); publishErr != nil {
+			slog.Error("Failed to publish outbox event", "id", event.ID, "event_id", event.Change.EventID, "error", publishErr)
+			break
+		}
+		published = append(published, event.ID)
+	}
+
+	if len(published) > 0 {
+		if _, err := tx.Exec("UPDATE outbox SET published_at = now() WHERE id = ANY($1::bigint[])", pq.Array(published)); err != nil {
+			slog.Error("Failed to mark outbox events published", "error", err)
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		slog.Error("Failed to commit transaction", "error", err)
+		return 0, err
+	}
+	return len(published), publishErr
+}
+
+func (r *PostgresOutboxRepository) PurgePublished(before time.Time) (int64, error) {
+	slog.Info("Purging published outbox events", "before", before)
+
+	result, err := r.db.Exec("DELETE FROM outbox WHERE published_at < $1", before)
+	if err != nil {
+		slog.Error("Failed to purge outbox", "error", err)
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("Failed to count purged outbox events", "error", err)
+		return 0, err
+	}
+
+	slog.Info("Outbox purged successfully", "count", purged)
+	return purged, nil
+}
+
+// queryOutbox reads the outbox events selected by the given WHERE, ORDER BY
+// and LIMIT clauses.
+func queryOutbox(q queryer, clauses string, args ...interface{}) ([]domain.OutboxEvent, error) {
+	rows, err := q.Query("SELECT id, event_id, payload, created_at FROM outbox "+clauses, args...)
+	if err != nil {
+		slog.Error("Failed to query outbox", "error", err)
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Failed to close rows", "error", err)
+		}
+	}()
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		var e domain.OutboxEvent
+		var eventID string
+		var payload []byte
+		if err := rows.Scan(&e.ID, &eventID, &payload, &e.CreatedAt); err != nil {
+			slog.Error("Failed to scan outbox row", "error", err)
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &e.Change); err != nil {
+			slog.Error("Failed to decode outbox event", "id", e.ID, "error", err)
+			return nil, err
+		}
+		e.Change.EventID = eventID
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Error iterating rows", "error", err)
+		return nil, err
+	}
+	return events, nil
+}
+
+// txn is a transaction of the task repository. The changes it announces
+// are written to the outbox by flushOutbox right before it commits.
+type txn struct {
+	*sql.Tx
+	changes []domain.ChangeEvent
+}
+
+// announce queues change to be written to the outbox when tx commits.
+func (tx *txn) announce(change domain.ChangeEvent) {
+	tx.changes = append(tx.changes, change)
+}
+
+// flushOutbox writes the changes announced in tx to the outbox in one
+// statement. It takes outboxWriteLock, held until tx commits, so outbox ids
+// are handed out in commit order and readers following the outbox by id
+// never pass a row that is yet to commit. Only this insert and the commit
+// are serialized: the writes themselves run concurrently under their row
+// locks, and since nothing is locked after it, it cannot deadlock.
+func flushOutbox(tx *txn) error {
+	if len(tx.changes) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int64, len(tx.changes))
+	types := make([]string, len(tx.changes))
+	payloads := make([]string, len(tx.changes))
+	for i, change := range tx.changes {
+		payload, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		taskIDs[i], types[i], payloads[i] = change.TaskID, string(change.Type), string(payload)
+	}
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", outboxWriteLock); err != nil {
+		slog.Error("Failed to lock outbox", "error", err)
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO outbox (task_id, type, payload)
+		SELECT NULLIF(c.task_id, 0), c.type, c.payload::jsonb
+		FROM unnest($1::bigint[], $2::text[], $3::text[]) WITH ORDINALITY AS c(task_id, type, payload, n)
+		ORDER BY c.n`,
+		pq.Array(taskIDs), pq.Array(types), pq.Array(payloads))
+	if err != nil {
+		slog.Error("Failed to write outbox events", "count", len(tx.changes), "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"prova-fattocs/internal/domain"
+)
+
+func TestOutbox_RecordsCommittedWritesInOrder(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresTaskRepository(db)
+	outbox := NewPostgresOutboxRepository(db)
+
+	deadline := domain.NewDate(2025, time.August, 10)
+	first, err := repo.Create(domain.Task{Name: "Task 1", Cost: 10_00, Deadline: deadline})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	second, err := repo.Create(domain.Task{Name: "Task 2", Cost: 10_00, Deadline: deadline})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	if _, err := repo.Update(first.ID, domain.Task{Name: "Task 2", Cost: 10_00, Deadline: deadline}); !errors.Is(err, domain.ErrDuplicateName) {
+		t.Fatalf("expected duplicate name error but got %v", err)
+	}
+	if err := repo.Reorder(second.ID, 1, 0); err != nil {
+		t.Fatalf("failed to reorder tasks: %v", err)
+	}
+	if err := repo.Delete(first.ID, 0); err != nil {
+		t.Fatalf("failed to delete task: %v", err)
+	}
+
+	var events []domain.OutboxEvent
+	n, err := outbox.Dispatch(100, func(e domain.OutboxEvent) error {
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to dispatch outbox: %v", err)
+	}
+	if n != 4 || len(events) != 4 {
+		t.Fatalf("expected 4 events but got %d: %+v", n, events)
+	}
+
+	want := []domain.ChangeType{domain.ChangeCreated, domain.ChangeCreated, domain.ChangeReordered, domain.ChangeDeleted}
+	for i, e := range events {
+		if e.Change.Type != want[i] || e.Change.EventID == "" {
+			t.Errorf("event %d: expected %s with an event id but got %+v", i, want[i], e.Change)
+		}
+	}
+	if order := events[2].Change.Order; len(order) != 2 || order[0] != second.ID {
+		t.Errorf("expected task %d to be first but got %v", second.ID, order)
+	}
+
+	n, err = outbox.Dispatch(100, func(domain.OutboxEvent) error {
+		t.Error("expected published events not to be dispatched again")
+		return nil
+	})
+	if err != nil || n != 0 {
+		t.Errorf("expected nothing left to dispatch but got %d (%v)", n, err)
+	}
+
+	after, err := outbox.After(events[1].ID, 10)
+	if err != nil || len(after) != 2 || after[0].ID != events[2].ID {
+		t.Errorf("expected the last two events after the second but got %+v (%v)", after, err)
+	}
+	if last, err := outbox.LastID(); err != nil || last != events[3].ID {
+		t.Errorf("expected last id %d but got %d (%v)", events[3].ID, last, err)
+	}
+
+	purged, err := outbox.PurgePublished(time.Now().Add(time.Minute))
+	if err != nil || purged != 4 {
+		t.Errorf("expected 4 published events to be purged but got %d (%v)", purged, err)
+	}
+}
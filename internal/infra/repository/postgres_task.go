@@ -2,22 +2,62 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"html"
 	"log/slog"
 	"prova-fattocs/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
+// taskColumns lists the columns scanTask expects, in order. Deleted tasks
+// have no presentation_order and report 0.
+const taskColumns = "id, name, cost, deadline, COALESCE(presentation_order, 0) AS presentation_order, status, completed_at, deleted_at, version"
+
+// TaskRepository persists tasks. Deleted tasks stay in the trash, invisible to
+// every method but ListDeleted, Restore and PurgeDeleted, until purged.
+// Methods taking a version apply the write only while the stored version
+// still matches it and return domain.ErrPreconditionFailed otherwise; a
+// version of 0 skips the check. Every write appends to the audit log in the
+// same transaction, attributed to the AuditInfo given to WithAudit.
 type TaskRepository interface {
-	List() ([]domain.Task, error)
-	Create(task domain.Task) error
-	Update(id int64, task domain.Task) error
-	Delete(id int64) error
-	Reorder(id int64, direction int64) error
+	WithAudit(info domain.AuditInfo) TaskRepository
+	Transaction(fn func(repo TaskRepository) error) error
+
+	List(opts domain.ListOptions) ([]domain.Task, int, error)
+	ListAfter(opts domain.ListOptions, after *domain.Task, limit int) ([]domain.Task, error)
+	GetByID(id int64) (domain.Task, error)
+	GetByName(name string) (domain.Task, error)
+	Search(query string, limit int) ([]domain.SearchResult, error)
+	Create(task domain.Task) (domain.Task, error)
+	Update(id int64, task domain.Task) (domain.Task, error)
+	Patch(id int64, patch domain.TaskPatch) (domain.Task, error)
+	Delete(id int64, version int64) error
+	ListDeleted() ([]domain.Task, error)
+	Restore(id int64, version int64) (domain.Task, error)
+	PurgeDeleted(before time.Time) (int64, error)
+	ListRevisions(id int64) ([]domain.TaskRevision, error)
+	GetRevision(id int64, revision int) (domain.TaskRevision, error)
+	Revert(id int64, revision int, version int64) (domain.Task, error)
+	UpdateStatus(id int64, from, to domain.Status, version int64) (domain.Task, error)
+	Reorder(id int64, position int64, version int64) error
+	ReorderAll(ids []int64, version string) ([]domain.Task, string, error)
 	ExistsByName(name string, id int64) (bool, error)
+	LastChange() (int64, time.Time, error)
+	ListVersion() (string, error)
 }
 
 type PostgresTaskRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	audit domain.AuditInfo
+
+	// tx is set on repositories handed out by Transaction; every method then
+	// runs inside it.
+	tx *txn
 }
 
 func NewPostgresTaskRepository(db *sql.DB) TaskRepository {
@@ -25,11 +65,166 @@ func NewPostgresTaskRepository(db *sql.DB) TaskRepository {
 	return &PostgresTaskRepository{db: db}
 }
 
-func (r *PostgresTaskRepository) List() ([]domain.Task, error) {
-	slog.Info("Listing all tasks")
-	rows, err := r.db.Query("SELECT id, name, cost, deadline, presentation_order FROM tasks ORDER BY presentation_order")
+// WithAudit returns a repository sharing the same connection pool whose
+// writes are attributed to info in the audit log.
+func (r *PostgresTaskRepository) WithAudit(info domain.AuditInfo) TaskRepository {
+	return &PostgresTaskRepository{db: r.db, audit: info, tx: r.tx}
+}
+
+// Transaction runs fn with a repository whose methods all share one
+// transaction, committed only if fn returns nil. Inside a transaction it just
+// calls fn.
+func (r *PostgresTaskRepository) Transaction(fn func(repo TaskRepository) error) error {
+	return r.withTx(func(tx *txn) error {
+		return fn(&PostgresTaskRepository{db: r.db, audit: r.audit, tx: tx})
+	})
+}
+
+// conn is where queries outside withTx run: the repository's transaction if
+// it has one, the pool otherwise.
+func (r *PostgresTaskRepository) conn() queryer {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// sortColumns maps the sort fields accepted in domain.ListOptions onto columns.
+var sortColumns = map[string]string{
+	"":         "presentation_order",
+	"order":    "presentation_order",
+	"name":     "name",
+	"cost":     "cost",
+	"deadline": "deadline",
+}
+
+// sortValue returns the value t holds in the column opts sort by.
+func sortValue(sort string, t domain.Task) interface{} {
+	switch sortColumns[sort] {
+	case "name":
+		return t.Name
+	case "cost":
+		return t.Cost
+	case "deadline":
+		return t.Deadline
+	default:
+		return t.OrderNumber
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// List returns the page of tasks selected by opts together with the number
+// of tasks matching its filters across all pages.
+func (r *PostgresTaskRepository) List(opts domain.ListOptions) ([]domain.Task, int, error) {
+	slog.Info("Listing tasks", "limit", opts.Limit, "page", opts.Page, "sort", opts.Sort, "desc", opts.Desc)
+
+	where, args := listFilters(opts)
+
+	var total int
+	if err := r.conn().QueryRow("SELECT COUNT(*) FROM tasks"+where, args...).Scan(&total); err != nil {
+		slog.Error("Failed to count tasks", "error", err)
+		return nil, 0, err
+	}
+
+	direction := " ASC"
+	if opts.Desc {
+		direction = " DESC"
+	}
+	query := "SELECT " + taskColumns + " FROM tasks" + where + " ORDER BY " + sortColumns[opts.Sort] + direction + ", id"
+	if opts.Limit > 0 {
+		args = append(args, opts.Limit, opts.Offset())
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	tasks, err := queryTasks(r.conn(), query, args...)
 	if err != nil {
-		slog.Error("Failed to query tasks", "error", err)
+		return nil, 0, err
+	}
+
+	slog.Info("Successfully listed tasks", "count", len(tasks), "total", total)
+	return tasks, total, nil
+}
+
+// ListAfter returns up to limit of the tasks selected by the filters and sort
+// of opts that come after the task after, or from the start if it is nil.
+// Unlike pages, consecutive batches read this way cost the same however deep
+// they are. Pagination in opts is ignored.
+func (r *PostgresTaskRepository) ListAfter(opts domain.ListOptions, after *domain.Task, limit int) ([]domain.Task, error) {
+	slog.Debug("Listing tasks after cursor", "limit", limit, "sort", opts.Sort, "desc", opts.Desc)
+
+	where, args := listFilters(opts)
+	column := sortColumns[opts.Sort]
+	direction, comparison := " ASC", ">"
+	if opts.Desc {
+		direction, comparison = " DESC", "<"
+	}
+	if after != nil {
+		args = append(args, sortValue(opts.Sort, *after), after.ID)
+		where += fmt.Sprintf(" AND (%[1]s %[2]s $%[3]d OR (%[1]s = $%[3]d AND id > $%[4]d))", column, comparison, len(args)-1, len(args))
+	}
+	args = append(args, limit)
+	query := "SELECT " + taskColumns + " FROM tasks" + where + " ORDER BY " + column + direction + ", id" + fmt.Sprintf(" LIMIT $%d", len(args))
+
+	return queryTasks(r.conn(), query, args...)
+}
+
+func (r *PostgresTaskRepository) GetByID(id int64) (domain.Task, error) {
+	slog.Info("Getting task by id", "id", id)
+
+	t, err := scanTask(r.conn().QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id=$1 AND deleted_at IS NULL", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Warn("Task not found", "id", id)
+		return domain.Task{}, domain.ErrNotFound
+	} else if err != nil {
+		slog.Error("Failed to get task", "id", id, "error", err)
+		return domain.Task{}, err
+	}
+
+	return t, nil
+}
+
+func (r *PostgresTaskRepository) GetByName(name string) (domain.Task, error) {
+	slog.Info("Getting task by name", "name", name)
+
+	t, err := scanTask(r.conn().QueryRow("SELECT "+taskColumns+" FROM tasks WHERE name=$1 AND deleted_at IS NULL", name))
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Warn("Task not found", "name", name)
+		return domain.Task{}, domain.ErrNotFound
+	} else if err != nil {
+		slog.Error("Failed to get task", "name", name, "error", err)
+		return domain.Task{}, err
+	}
+
+	return t, nil
+}
+
+// Highlight delimiters handed to ts_headline. Control characters never show
+// up in task names, so the snippet can be HTML-escaped before they are
+// swapped for <mark> tags.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+var highlighter = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// Search ranks tasks whose name matches query using the accent-insensitive
+// Portuguese text search configuration. query accepts web search syntax:
+// quoted phrases, "or" and a leading "-" to exclude words.
+func (r *PostgresTaskRepository) Search(query string, limit int) ([]domain.SearchResult, error) {
+	slog.Info("Searching tasks", "query", query, "limit", limit)
+
+	rows, err := r.conn().Query(`SELECT `+taskColumns+`,
+			ts_rank(search_vector, q) AS rank,
+			ts_headline('public.portuguese_unaccent', name, q, $2) AS snippet
+		FROM tasks, websearch_to_tsquery('public.portuguese_unaccent', $1) AS q
+		WHERE search_vector @@ q AND deleted_at IS NULL
+		ORDER BY rank DESC, presentation_order
+		LIMIT $3`,
+		query, fmt.Sprintf(`StartSel="%s", StopSel="%s", HighlightAll=true`, highlightStart, highlightStop), limit)
+	if err != nil {
+		slog.Error("Failed to search tasks", "query", query, "error", err)
 		return nil, err
 	}
 	defer func() {
@@ -38,15 +233,17 @@ func (r *PostgresTaskRepository) List() ([]domain.Task, error) {
 		}
 	}()
 
-	var tasks []domain.Task
+	var results []domain.SearchResult
 	for rows.Next() {
-		var t domain.Task
-		err := rows.Scan(&t.ID, &t.Name, &t.Cost, &t.Deadline, &t.OrderNumber)
+		var res domain.SearchResult
+		var err error
+		res.Task, err = scanTask(rows, &res.Rank, &res.Snippet)
 		if err != nil {
-			slog.Error("Failed to scan task row", "error", err)
+			slog.Error("Failed to scan search result", "error", err)
 			return nil, err
 		}
-		tasks = append(tasks, t)
+		res.Snippet = highlighter.Replace(html.EscapeString(res.Snippet))
+		results = append(results, res)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -54,103 +251,400 @@ func (r *PostgresTaskRepository) List() ([]domain.Task, error) {
 		return nil, err
 	}
 
-	slog.Info("Successfully listed tasks", "count", len(tasks))
-	return tasks, nil
+	slog.Info("Search completed", "query", query, "count", len(results))
+	return results, nil
 }
 
-func (r *PostgresTaskRepository) Create(task domain.Task) error {
+// Create inserts the task at the end of the list. Order allocation happens in
+// the INSERT itself under the ordering lock, and duplicate names are caught
+// by the UNIQUE constraint, so concurrent creates cannot race each other.
+func (r *PostgresTaskRepository) Create(task domain.Task) (domain.Task, error) {
 	slog.Info("Creating new task", "name", task.Name, "cost", task.Cost, "deadline", task.Deadline)
 
-	exists, err := r.ExistsByName(task.Name, task.ID)
+	var created domain.Task
+	err := r.withTx(func(tx *txn) error {
+		if err := lockOrdering(tx); err != nil {
+			return err
+		}
+
+		var err error
+		created, err = scanTask(tx.QueryRow(`INSERT INTO tasks (name, cost, deadline, presentation_order)
+			SELECT $1, $2, $3, COALESCE(MAX(presentation_order), 0) + 1 FROM tasks
+			RETURNING `+taskColumns,
+			task.Name, task.Cost, task.Deadline))
+		if err != nil {
+			if err = translateError(err); errors.Is(err, domain.ErrDuplicateName) {
+				slog.Warn("Task with this name already exists", "name", task.Name)
+				return err
+			}
+			slog.Error("Failed to insert task", "name", task.Name, "error", err)
+			return err
+		}
+		if err := saveRevision(tx, created); err != nil {
+			return err
+		}
+		return r.recordEvent(tx, domain.ActionCreated, nil, &created)
+	})
 	if err != nil {
-		slog.Error("Failed to check if task exists", "name", task.Name, "error", err)
-		return err
-	}
-	if exists {
-		slog.Warn("Task with this name already exists", "name", task.Name)
-		return errors.New("task with this name already exists")
+		return domain.Task{}, err
 	}
 
-	var maxOrder int
-	err = r.db.QueryRow("SELECT COALESCE(MAX(presentation_order), 0) FROM tasks").Scan(&maxOrder)
+	slog.Info("Task created successfully", "id", created.ID, "name", created.Name)
+	return created, nil
+}
+
+func (r *PostgresTaskRepository) Update(id int64, task domain.Task) (domain.Task, error) {
+	slog.Info("Updating task", "id", id, "name", task.Name, "cost", task.Cost, "deadline", task.Deadline)
+
+	var updated domain.Task
+	err := r.withTx(func(tx *txn) error {
+		before, err := lockTask(tx, id, task.Version)
+		if err != nil {
+			return err
+		}
+
+		updated, err = scanTask(tx.QueryRow(`UPDATE tasks SET name=$1, cost=$2, deadline=$3, version = version + 1
+			WHERE id=$4
+			RETURNING `+taskColumns,
+			task.Name, task.Cost, task.Deadline, id))
+		if err != nil {
+			if err = translateError(err); errors.Is(err, domain.ErrDuplicateName) {
+				slog.Warn("Task with this name already exists", "name", task.Name)
+				return err
+			}
+			slog.Error("Failed to update task", "id", id, "error", err)
+			return err
+		}
+		if err := saveRevision(tx, updated); err != nil {
+			return err
+		}
+		return r.recordEvent(tx, domain.ActionUpdated, &before, &updated)
+	})
 	if err != nil {
-		slog.Error("Failed to get max presentation order", "error", err)
-		return err
+		return domain.Task{}, err
 	}
-	task.OrderNumber = maxOrder + 1
 
-	result, err := r.db.Exec("INSERT INTO tasks (name, cost, deadline, presentation_order) VALUES ($1, $2, $3, $4)",
-		task.Name, task.Cost, task.Deadline, task.OrderNumber)
+	slog.Info("Task updated successfully", "id", id)
+	return updated, nil
+}
+
+// Patch writes only the fields present in patch, so concurrent edits to
+// other fields are not overwritten.
+func (r *PostgresTaskRepository) Patch(id int64, patch domain.TaskPatch) (domain.Task, error) {
+	slog.Info("Patching task", "id", id)
+
+	var patched domain.Task
+	err := r.withTx(func(tx *txn) error {
+		before, err := lockTask(tx, id, patch.Version)
+		if err != nil {
+			return err
+		}
+
+		patched, err = scanTask(tx.QueryRow(`UPDATE tasks SET
+				name = COALESCE($1::varchar, name),
+				cost = COALESCE($2::numeric, cost),
+				deadline = COALESCE($3::date, deadline),
+				version = version + 1
+			WHERE id=$4
+			RETURNING `+taskColumns,
+			patch.Name, patch.Cost, patch.Deadline, id))
+		if err != nil {
+			if err = translateError(err); errors.Is(err, domain.ErrDuplicateName) {
+				slog.Warn("Task with this name already exists", "id", id)
+				return err
+			}
+			slog.Error("Failed to patch task", "id", id, "error", err)
+			return err
+		}
+		if err := saveRevision(tx, patched); err != nil {
+			return err
+		}
+		return r.recordEvent(tx, domain.ActionUpdated, &before, &patched)
+	})
 	if err != nil {
-		slog.Error("Failed to insert task", "name", task.Name, "error", err)
-		return err
+		return domain.Task{}, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	slog.Info("Task patched successfully", "id", id)
+	return patched, nil
+}
+
+// UpdateStatus moves the task from one status to another, stamping
+// completed_at when it becomes done and clearing it otherwise. The write is
+// guarded on the current status so two concurrent transitions cannot both
+// apply; the loser gets domain.ErrConflict.
+func (r *PostgresTaskRepository) UpdateStatus(id int64, from, to domain.Status, version int64) (domain.Task, error) {
+	slog.Info("Updating task status", "id", id, "from", from, "to", to, "version", version)
+
+	var updated domain.Task
+	err := r.withTx(func(tx *txn) error {
+		before, err := lockTask(tx, id, version)
+		if err != nil {
+			return err
+		}
+		if before.Status != from {
+			slog.Warn("Task status changed concurrently", "id", id, "expected", from, "actual", before.Status)
+			return fmt.Errorf("%w: task is no longer %s", domain.ErrConflict, from)
+		}
+
+		updated, err = scanTask(tx.QueryRow(`UPDATE tasks SET
+				status = $2,
+				completed_at = CASE WHEN $2 = 'done' THEN now() END,
+				version = version + 1
+			WHERE id=$1
+			RETURNING `+taskColumns,
+			id, to))
+		if err != nil {
+			slog.Error("Failed to update task status", "id", id, "error", err)
+			return err
+		}
+		return r.recordEvent(tx, domain.ActionStatusChanged, &before, &updated)
+	})
 	if err != nil {
-		slog.Error("Failed to get rows affected", "error", err)
-	} else {
-		slog.Info("Task created successfully", "name", task.Name, "rows_affected", rowsAffected)
+		return domain.Task{}, err
 	}
 
-	return err
+	slog.Info("Task status updated successfully", "id", id, "status", to)
+	return updated, nil
 }
 
-func (r *PostgresTaskRepository) Update(id int64, task domain.Task) error {
-	slog.Info("Updating task", "id", id, "name", task.Name, "cost", task.Cost, "deadline", task.Deadline)
+// Delete moves the task to the trash. It gives up its slot in the ordering
+// and its name becomes available to other tasks.
+func (r *PostgresTaskRepository) Delete(id, version int64) error {
+	slog.Info("Deleting task", "id", id, "version", version)
 
-	var exists bool
-	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE name=$1 AND id<>$2)", task.Name, id).Scan(&exists)
+	err := r.withTx(func(tx *txn) error {
+		if err := lockOrdering(tx); err != nil {
+			return err
+		}
+		before, err := lockTask(tx, id, version)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := scanTask(tx.QueryRow(`UPDATE tasks SET deleted_at = now(), presentation_order = NULL, version = version + 1
+			WHERE id=$1
+			RETURNING `+taskColumns, id))
+		if err != nil {
+			slog.Error("Failed to delete task", "id", id, "error", err)
+			return err
+		}
+		if err := r.recordEvent(tx, domain.ActionDeleted, &before, &deleted); err != nil {
+			return err
+		}
+
+		return renumber(tx)
+	})
 	if err != nil {
-		slog.Error("Failed to check if another task with same name exists", "name", task.Name, "id", id, "error", err)
 		return err
 	}
-	if exists {
-		slog.Warn("Task with this name already exists", "name", task.Name)
-		return errors.New("task with this name already exists")
+
+	slog.Info("Task deleted successfully", "id", id)
+	return nil
+}
+
+// ListDeleted returns the tasks in the trash, most recently deleted first.
+func (r *PostgresTaskRepository) ListDeleted() ([]domain.Task, error) {
+	slog.Info("Listing deleted tasks")
+	tasks, err := queryTasks(r.conn(), "SELECT "+taskColumns+" FROM tasks WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id")
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := r.db.Exec("UPDATE tasks SET name=$1, cost=$2, deadline=$3 WHERE id=$4",
-		task.Name, task.Cost, task.Deadline, id)
+	slog.Info("Successfully listed deleted tasks", "count", len(tasks))
+	return tasks, nil
+}
+
+// Restore takes the task out of the trash and puts it at the end of the list.
+// It fails with domain.ErrDuplicateName if another task took its name since.
+func (r *PostgresTaskRepository) Restore(id, version int64) (domain.Task, error) {
+	slog.Info("Restoring task", "id", id, "version", version)
+
+	var restored domain.Task
+	err := r.withTx(func(tx *txn) error {
+		if err := lockOrdering(tx); err != nil {
+			return err
+		}
+
+		before, err := scanTask(tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id=$1 AND deleted_at IS NOT NULL FOR UPDATE", id))
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("Task not found in trash", "id", id)
+			return domain.ErrNotFound
+		} else if err != nil {
+			slog.Error("Failed to get deleted task", "id", id, "error", err)
+			return err
+		}
+		if version != 0 && version != before.Version {
+			slog.Warn("Task version does not match", "id", id, "expected", version, "actual", before.Version)
+			return domain.ErrPreconditionFailed
+		}
+
+		restored, err = scanTask(tx.QueryRow(`UPDATE tasks SET
+				deleted_at = NULL,
+				presentation_order = (SELECT COALESCE(MAX(presentation_order), 0) + 1 FROM tasks),
+				version = version + 1
+			WHERE id=$1
+			RETURNING `+taskColumns, id))
+		if err != nil {
+			if err = translateError(err); errors.Is(err, domain.ErrDuplicateName) {
+				slog.Warn("Task with this name already exists", "id", id)
+				return err
+			}
+			slog.Error("Failed to restore task", "id", id, "error", err)
+			return err
+		}
+		return r.recordEvent(tx, domain.ActionRestored, &before, &restored)
+	})
 	if err != nil {
-		slog.Error("Failed to update task", "id", id, "error", err)
-		return err
+		return domain.Task{}, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	slog.Info("Task restored successfully", "id", id, "order", restored.OrderNumber)
+	return restored, nil
+}
+
+// PurgeDeleted permanently removes the tasks deleted before the given time.
+// Their history stays in the audit log.
+func (r *PostgresTaskRepository) PurgeDeleted(before time.Time) (int64, error) {
+	slog.Info("Purging deleted tasks", "before", before)
+
+	var purged []domain.Task
+	err := r.withTx(func(tx *txn) error {
+		var err error
+		purged, err = queryTasks(tx, "DELETE FROM tasks WHERE deleted_at < $1 RETURNING "+taskColumns, before)
+		if err != nil {
+			slog.Error("Failed to purge deleted tasks", "error", err)
+			return err
+		}
+
+		for i := range purged {
+			if err := r.recordEvent(tx, domain.ActionPurged, &purged[i], nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		slog.Error("Failed to get rows affected", "error", err)
-	} else {
-		slog.Info("Task updated successfully", "id", id, "rows_affected", rowsAffected)
+		return 0, err
 	}
 
-	return err
+	slog.Info("Deleted tasks purged successfully", "count", len(purged))
+	return int64(len(purged)), nil
 }
 
-func (r *PostgresTaskRepository) Delete(id int64) error {
-	slog.Info("Deleting task", "id", id)
+// ListRevisions returns the saved states of a task, newest first, whether or
+// not it is in the trash.
+func (r *PostgresTaskRepository) ListRevisions(id int64) ([]domain.TaskRevision, error) {
+	slog.Info("Listing task revisions", "id", id)
 
-	result, err := r.db.Exec("DELETE FROM tasks WHERE id=$1", id)
+	rows, err := r.conn().Query("SELECT "+revisionColumns+" FROM task_revisions WHERE task_id=$1 ORDER BY revision DESC", id)
 	if err != nil {
-		slog.Error("Failed to delete task", "id", id, "error", err)
-		return err
+		slog.Error("Failed to query task revisions", "id", id, "error", err)
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Failed to close rows", "error", err)
+		}
+	}()
+
+	var revisions []domain.TaskRevision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			slog.Error("Failed to scan task revision row", "error", err)
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Error iterating rows", "error", err)
+		return nil, err
+	}
+
+	// Every task has at least the revision saved when it was created.
+	if len(revisions) == 0 {
+		slog.Warn("Task not found", "id", id)
+		return nil, domain.ErrNotFound
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	slog.Info("Successfully listed task revisions", "id", id, "count", len(revisions))
+	return revisions, nil
+}
+
+func (r *PostgresTaskRepository) GetRevision(id int64, revision int) (domain.TaskRevision, error) {
+	slog.Info("Getting task revision", "id", id, "revision", revision)
+	return getRevision(r.conn(), id, revision)
+}
+
+// Revert restores the name, cost and deadline saved in a revision, taking
+// the task out of the trash first if needed. The reverted state is saved as
+// a new revision, so a revert can itself be reverted.
+func (r *PostgresTaskRepository) Revert(id int64, revision int, version int64) (domain.Task, error) {
+	slog.Info("Reverting task", "id", id, "revision", revision, "version", version)
+
+	var reverted domain.Task
+	err := r.withTx(func(tx *txn) error {
+		if err := lockOrdering(tx); err != nil {
+			return err
+		}
+
+		before, err := scanTask(tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id=$1 FOR UPDATE", id))
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("Task not found", "id", id)
+			return domain.ErrNotFound
+		} else if err != nil {
+			slog.Error("Failed to lock task", "id", id, "error", err)
+			return err
+		}
+		if version != 0 && version != before.Version {
+			slog.Warn("Task version does not match", "id", id, "expected", version, "actual", before.Version)
+			return domain.ErrPreconditionFailed
+		}
+
+		snapshot, err := getRevision(tx, id, revision)
+		if err != nil {
+			return err
+		}
+
+		reverted, err = scanTask(tx.QueryRow(`UPDATE tasks SET
+				name = $2,
+				cost = $3,
+				deadline = $4,
+				presentation_order = CASE WHEN deleted_at IS NULL THEN presentation_order
+					ELSE (SELECT COALESCE(MAX(presentation_order), 0) + 1 FROM tasks) END,
+				deleted_at = NULL,
+				version = version + 1
+			WHERE id=$1
+			RETURNING `+taskColumns,
+			id, snapshot.Name, snapshot.Cost, snapshot.Deadline))
+		if err != nil {
+			if err = translateError(err); errors.Is(err, domain.ErrDuplicateName) {
+				slog.Warn("Task with this name already exists", "name", snapshot.Name)
+				return err
+			}
+			slog.Error("Failed to revert task", "id", id, "error", err)
+			return err
+		}
+
+		if err := saveRevision(tx, reverted); err != nil {
+			return err
+		}
+		return r.recordEvent(tx, domain.ActionReverted, &before, &reverted)
+	})
 	if err != nil {
-		slog.Error("Failed to get rows affected", "error", err)
-	} else {
-		slog.Info("Task deleted successfully", "id", id, "rows_affected", rowsAffected)
+		return domain.Task{}, err
 	}
 
-	return err
+	slog.Info("Task reverted successfully", "id", id, "revision", revision)
+	return reverted, nil
 }
 
 func (r *PostgresTaskRepository) ExistsByName(name string, id int64) (bool, error) {
 	slog.Debug("Checking if task exists by name", "name", name, "id", id)
 
 	var exists bool
-	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE name=$1 AND id<>$2)", name, id).Scan(&exists)
+	err := r.conn().QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE name=$1 AND id<>$2 AND deleted_at IS NULL)", name, id).Scan(&exists)
 	if err != nil {
 		slog.Error("Failed to check if task exists", "name", name, "error", err)
 		return false, err
@@ -160,59 +654,499 @@ func (r *PostgresTaskRepository) ExistsByName(name string, id int64) (bool, erro
 	return exists, err
 }
 
-func (r *PostgresTaskRepository) Reorder(id, direction int64) error {
-	slog.Info("Reordering task", "id", id, "direction", direction)
+// LastChange returns the ID and time of the latest entry in the audit log,
+// which every write appends to, or zero values if there is none.
+func (r *PostgresTaskRepository) LastChange() (int64, time.Time, error) {
+	slog.Debug("Getting last task change")
+
+	var id int64
+	var at time.Time
+	err := r.conn().QueryRow("SELECT id, created_at FROM task_events ORDER BY id DESC LIMIT 1").Scan(&id, &at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, time.Time{}, nil
+	} else if err != nil {
+		slog.Error("Failed to get last task change", "error", err)
+		return 0, time.Time{}, err
+	}
+	return id, at, nil
+}
+
+// Reorder moves the task to the given 1-based position, shifting every task
+// in between by one slot so presentation_order stays contiguous. Moving tasks
+// does not change their version, so editors of the shifted tasks, or of the
+// moved one, can still save.
+func (r *PostgresTaskRepository) Reorder(id, position, version int64) error {
+	slog.Info("Reordering task", "id", id, "position", position, "version", version)
+
+	err := r.withTx(func(tx *txn) error {
+		if err := lockOrdering(tx); err != nil {
+			return err
+		}
+		if err := renumber(tx); err != nil {
+			return err
+		}
+
+		before, err := lockTask(tx, id, version)
+		if err != nil {
+			return err
+		}
+		current := int64(before.OrderNumber)
+
+		var count int64
+		if err := tx.QueryRow("SELECT COUNT(*) FROM tasks WHERE deleted_at IS NULL").Scan(&count); err != nil {
+			slog.Error("Failed to count tasks", "error", err)
+			return err
+		}
+		if position < 1 || position > count {
+			slog.Warn("Requested position is out of range", "position", position, "count", count)
+			return domain.NewValidationError(domain.FieldError{
+				Field:   "order",
+				Code:    "out_of_range",
+				Message: fmt.Sprintf("position must be between 1 and %d", count),
+			})
+		}
+		if position == current {
+			return nil
+		}
+
+		if position < current {
+			_, err = tx.Exec(`UPDATE tasks SET presentation_order = presentation_order + 1
+				WHERE presentation_order >= $1 AND presentation_order < $2`, position, current)
+		} else {
+			_, err = tx.Exec(`UPDATE tasks SET presentation_order = presentation_order - 1
+				WHERE presentation_order > $1 AND presentation_order <= $2`, current, position)
+		}
+		if err != nil {
+			slog.Error("Failed to shift tasks between positions", "from", current, "to", position, "error", err)
+			return err
+		}
 
-	var currentOrder int
-	err := r.db.QueryRow("SELECT presentation_order FROM tasks WHERE id=$1", id).Scan(&currentOrder)
+		moved, err := scanTask(tx.QueryRow("UPDATE tasks SET presentation_order=$1 WHERE id=$2 RETURNING "+taskColumns, position, id))
+		if err != nil {
+			slog.Error("Failed to update presentation order", "id", id, "order", position, "error", err)
+			return err
+		}
+		// Only the moved task is recorded; the tasks shifted around it
+		// follow from the move.
+		if err := r.recordEvent(tx, domain.ActionReordered, &before, &moved); err != nil {
+			return err
+		}
+		return recordOrder(tx)
+	})
 	if err != nil {
-		slog.Error("Failed to get current presentation order", "id", id, "error", err)
 		return err
 	}
 
-	swapOrder := direction
+	slog.Info("Task reordered successfully", "id", id, "position", position)
+	return nil
+}
 
-	var swapID int64
-	err = r.db.QueryRow("SELECT id FROM tasks WHERE presentation_order=$1", swapOrder).Scan(&swapID)
-	if err == sql.ErrNoRows {
-		slog.Info("No task found to swap with", "swap_order", swapOrder)
-		return nil
-	} else if err != nil {
-		slog.Error("Failed to get task for swapping", "swap_order", swapOrder, "error", err)
+// ReorderAll rewrites every presentation_order at once so that tasks follow
+// the order of ids, which must list each existing task exactly once. Unlike
+// the other writes it is conditioned on the ListVersion of every task rather
+// than on a task version; an empty version skips the check. It returns the
+// tasks in their new order and the new ListVersion.
+func (r *PostgresTaskRepository) ReorderAll(ids []int64, version string) ([]domain.Task, string, error) {
+	slog.Info("Reordering all tasks", "count", len(ids), "version", version)
+
+	var tasks []domain.Task
+	var newVersion string
+	err := r.withTx(func(tx *txn) error {
+		if err := lockOrdering(tx); err != nil {
+			return err
+		}
+		if version != "" {
+			current, err := listVersion(tx)
+			if err != nil {
+				return err
+			}
+			if current != version {
+				slog.Warn("Task list changed since it was read", "expected", version, "current", current)
+				return domain.ErrPreconditionFailed
+			}
+		}
+
+		var unknown, missing int
+		err := tx.QueryRow(`SELECT
+				(SELECT COUNT(*) FROM unnest($1::bigint[]) AS i(id)
+					WHERE NOT EXISTS (SELECT 1 FROM tasks t WHERE t.id = i.id AND t.deleted_at IS NULL)),
+				(SELECT COUNT(*) FROM tasks t WHERE t.id <> ALL($1::bigint[]) AND t.deleted_at IS NULL)`, pq.Array(ids)).Scan(&unknown, &missing)
+		if err != nil {
+			slog.Error("Failed to compare ids with existing tasks", "error", err)
+			return err
+		}
+		var fields []domain.FieldError
+		if unknown > 0 {
+			fields = append(fields, domain.FieldError{
+				Field:   "ids",
+				Code:    "unknown_id",
+				Message: fmt.Sprintf("%d id(s) do not match any task", unknown),
+			})
+		}
+		if missing > 0 {
+			fields = append(fields, domain.FieldError{
+				Field:   "ids",
+				Code:    "missing_id",
+				Message: fmt.Sprintf("%d task(s) are missing from the list", missing),
+			})
+		}
+		if len(fields) > 0 {
+			slog.Warn("Reorder ids do not match existing tasks", "unknown", unknown, "missing", missing)
+			return domain.NewValidationError(fields...)
+		}
+
+		before, err := queryTasks(tx, "SELECT "+taskColumns+" FROM tasks WHERE deleted_at IS NULL")
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE tasks t SET presentation_order = o.position
+			FROM unnest($1::bigint[]) WITH ORDINALITY AS o(id, position)
+			WHERE t.id = o.id AND t.presentation_order <> o.position`, pq.Array(ids))
+		if err != nil {
+			slog.Error("Failed to rewrite presentation order", "error", err)
+			return err
+		}
+
+		tasks, err = queryTasks(tx, "SELECT "+taskColumns+" FROM tasks WHERE deleted_at IS NULL ORDER BY presentation_order")
+		if err != nil {
+			return err
+		}
+
+		previous := make(map[int64]domain.Task, len(before))
+		for _, t := range before {
+			previous[t.ID] = t
+		}
+		changed := false
+		for i := range tasks {
+			old := previous[tasks[i].ID]
+			if old.OrderNumber == tasks[i].OrderNumber {
+				continue
+			}
+			if err := r.recordEvent(tx, domain.ActionReordered, &old, &tasks[i]); err != nil {
+				return err
+			}
+			changed = true
+		}
+		if changed {
+			if err := recordOrder(tx); err != nil {
+				return err
+			}
+		}
+		newVersion, err = listVersion(tx)
 		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	slog.Info("Tasks reordered successfully", "count", len(tasks))
+	return tasks, newVersion, nil
+}
+
+// ListVersion identifies the state of the tasks outside the trash. It
+// changes whenever one of them is created, deleted, restored, edited or
+// moved, so it serves as the entity tag of the task list.
+func (r *PostgresTaskRepository) ListVersion() (string, error) {
+	slog.Debug("Getting task list version")
+	return listVersion(r.conn())
+}
+
+func listVersion(q queryer) (string, error) {
+	var version string
+	err := q.QueryRow(`SELECT md5(COALESCE(string_agg(id || ':' || version || ':' || presentation_order, ',' ORDER BY id), ''))
+		FROM tasks WHERE deleted_at IS NULL`).Scan(&version)
+	if err != nil {
+		slog.Error("Failed to get task list version", "error", err)
+		return "", err
+	}
+	return version, nil
+}
+
+// withTx runs fn inside a transaction, committing when it returns nil and
+// rolling back otherwise. The changes fn announced are written to the outbox
+// right before committing. A repository bound by Transaction runs fn in its
+// own transaction and leaves committing to Transaction.
+func (r *PostgresTaskRepository) withTx(fn func(tx *txn) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
 	}
 
-	tx, err := r.db.Begin()
+	sqlTx, err := r.db.Begin()
 	if err != nil {
 		slog.Error("Failed to begin transaction", "error", err)
 		return err
 	}
+	tx := &txn{Tx: sqlTx}
 
-	defer func() {
-		if err != nil {
-			slog.Info("Rolling back transaction")
-			tx.Rollback()
+	err = fn(tx)
+	if err == nil {
+		err = flushOutbox(tx)
+	}
+	if err != nil {
+		slog.Info("Rolling back transaction")
+		if rbErr := tx.Rollback(); rbErr != nil {
+			slog.Error("Failed to roll back transaction", "error", rbErr)
 		}
-	}()
+		return err
+	}
 
-	_, err = tx.Exec("UPDATE tasks SET presentation_order=$1 WHERE id=$2", swapOrder, id)
-	if err != nil {
-		slog.Error("Failed to update presentation order for first task", "id", id, "order", swapOrder, "error", err)
+	if err := tx.Commit(); err != nil {
+		slog.Error("Failed to commit transaction", "error", err)
 		return err
 	}
+	return nil
+}
 
-	_, err = tx.Exec("UPDATE tasks SET presentation_order=$1 WHERE id=$2", currentOrder, swapID)
+// lockOrdering serializes every statement that rewrites presentation_order
+// and defers the UNIQUE check so rows can be shifted in place.
+func lockOrdering(tx *txn) error {
+	if _, err := tx.Exec("LOCK TABLE tasks IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		slog.Error("Failed to lock tasks table", "error", err)
+		return err
+	}
+	if _, err := tx.Exec("SET CONSTRAINTS tasks_presentation_order_key DEFERRED"); err != nil {
+		slog.Error("Failed to defer presentation order constraint", "error", err)
+		return err
+	}
+	return nil
+}
+
+// renumber closes any gap in presentation_order so tasks that are not deleted
+// are numbered 1..N.
+func renumber(tx *txn) error {
+	_, err := tx.Exec(`UPDATE tasks t SET presentation_order = o.position
+		FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY presentation_order) AS position
+			FROM tasks WHERE deleted_at IS NULL) o
+		WHERE t.id = o.id AND t.presentation_order <> o.position`)
 	if err != nil {
-		slog.Error("Failed to update presentation order for second task", "id", swapID, "order", currentOrder, "error", err)
+		slog.Error("Failed to renumber presentation order", "error", err)
+	}
+	return err
+}
+
+// translateError maps Postgres constraint violations onto domain errors.
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" && pqErr.Constraint == "tasks_name_key" {
+		return domain.ErrDuplicateName
+	}
+	return err
+}
+
+// listFilters turns the filters in opts into a WHERE clause and its arguments.
+func listFilters(opts domain.ListOptions) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if opts.CostMin != nil {
+		add("cost >= $%d", *opts.CostMin)
+	}
+	if opts.CostMax != nil {
+		add("cost <= $%d", *opts.CostMax)
+	}
+	if opts.DeadlineBefore != nil {
+		add("deadline < $%d", *opts.DeadlineBefore)
+	}
+	if opts.DeadlineAfter != nil {
+		add("deadline > $%d", *opts.DeadlineAfter)
+	}
+	if opts.NameContains != "" {
+		add("name ILIKE $%d", "%"+likeEscaper.Replace(opts.NameContains)+"%")
+	}
+	if len(opts.Statuses) > 0 {
+		statuses := make([]string, len(opts.Statuses))
+		for i, status := range opts.Statuses {
+			statuses[i] = string(status)
+		}
+		add("status = ANY($%d)", pq.Array(statuses))
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// lockTask reads the task and locks its row until the transaction ends. It
+// fails with domain.ErrNotFound if the task does not exist or is in the trash,
+// and with domain.ErrPreconditionFailed if version is not 0 and no longer
+// matches.
+func lockTask(tx *txn, id, version int64) (domain.Task, error) {
+	t, err := scanTask(tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id=$1 AND deleted_at IS NULL FOR UPDATE", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Warn("Task not found", "id", id)
+		return domain.Task{}, domain.ErrNotFound
+	} else if err != nil {
+		slog.Error("Failed to lock task", "id", id, "error", err)
+		return domain.Task{}, err
+	}
+	if version != 0 && version != t.Version {
+		slog.Warn("Task version does not match", "id", id, "expected", version, "actual", t.Version)
+		return domain.Task{}, domain.ErrPreconditionFailed
+	}
+	return t, nil
+}
+
+// recordEvent appends a change to the audit log within tx, attributed to the
+// repository's AuditInfo or, without an actor, to domain.SystemActor, and
+// announces it for the outbox.
+func (r *PostgresTaskRepository) recordEvent(tx *txn, action domain.EventAction, before, after *domain.Task) error {
+	var taskID int64
+	var oldData, newData interface{}
+	if before != nil {
+		taskID = before.ID
+		data, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		oldData = string(data)
+	}
+	if after != nil {
+		taskID = after.ID
+		data, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+		newData = string(data)
+	}
+
+	actor := r.audit.Actor
+	if actor == "" {
+		actor = domain.SystemActor
+	}
+
+	_, err := tx.Exec(`INSERT INTO task_events (task_id, action, old_data, new_data, actor, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6)`, taskID, action, oldData, newData, actor, r.audit.RequestID)
+	if err != nil {
+		slog.Error("Failed to record task event", "id", taskID, "action", action, "error", err)
 		return err
 	}
 
-	err = tx.Commit()
+	switch action {
+	case domain.ActionCreated:
+		tx.announce(domain.ChangeEvent{Type: domain.ChangeCreated, TaskID: taskID, Task: after})
+	case domain.ActionUpdated, domain.ActionStatusChanged, domain.ActionRestored, domain.ActionReverted:
+		tx.announce(domain.ChangeEvent{Type: domain.ChangeUpdated, TaskID: taskID, Task: after})
+	case domain.ActionDeleted:
+		tx.announce(domain.ChangeEvent{Type: domain.ChangeDeleted, TaskID: taskID})
+	}
+	// Reorders are announced once for the whole new order by recordOrder;
+	// purged tasks were already announced as deleted.
+	return nil
+}
+
+// recordOrder announces the order of every task after a reorder within tx.
+func recordOrder(tx *txn) error {
+	rows, err := tx.Query("SELECT id FROM tasks WHERE deleted_at IS NULL ORDER BY presentation_order")
 	if err != nil {
-		slog.Error("Failed to commit transaction", "error", err)
+		slog.Error("Failed to query task order", "error", err)
 		return err
 	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Failed to close rows", "error", err)
+		}
+	}()
 
-	slog.Info("Tasks reordered successfully", "task1_id", id, "task2_id", swapID)
+	order := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			slog.Error("Failed to scan task id", "error", err)
+			return err
+		}
+		order = append(order, id)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Error iterating rows", "error", err)
+		return err
+	}
+	tx.announce(domain.ChangeEvent{Type: domain.ChangeReordered, Order: order})
 	return nil
 }
+
+// saveRevision snapshots the editable fields of t as its next revision. The
+// caller must hold the task's row lock, or have just inserted it, so
+// revision numbers cannot collide.
+func saveRevision(tx *txn, t domain.Task) error {
+	_, err := tx.Exec(`INSERT INTO task_revisions (task_id, revision, name, cost, deadline)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4 FROM task_revisions WHERE task_id=$1`,
+		t.ID, t.Name, t.Cost, t.Deadline)
+	if err != nil {
+		slog.Error("Failed to save task revision", "id", t.ID, "error", err)
+	}
+	return err
+}
+
+const revisionColumns = "task_id, revision, name, cost, deadline, created_at"
+
+func getRevision(q queryer, id int64, revision int) (domain.TaskRevision, error) {
+	rev, err := scanRevision(q.QueryRow("SELECT "+revisionColumns+" FROM task_revisions WHERE task_id=$1 AND revision=$2", id, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Warn("Task revision not found", "id", id, "revision", revision)
+		return domain.TaskRevision{}, domain.ErrRevisionNotFound
+	} else if err != nil {
+		slog.Error("Failed to get task revision", "id", id, "revision", revision, "error", err)
+		return domain.TaskRevision{}, err
+	}
+	return rev, nil
+}
+
+func scanRevision(row scanner) (domain.TaskRevision, error) {
+	var rev domain.TaskRevision
+	err := row.Scan(&rev.TaskID, &rev.Revision, &rev.Name, &rev.Cost, &rev.Deadline, &rev.CreatedAt)
+	return rev, err
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask reads the taskColumns of a row, followed by any extra columns the
+// query selected after them.
+func scanTask(row scanner, extra ...interface{}) (domain.Task, error) {
+	var t domain.Task
+	dest := append([]interface{}{
+		&t.ID, &t.Name, &t.Cost, &t.Deadline, &t.OrderNumber, &t.Status, &t.CompletedAt, &t.DeletedAt, &t.Version,
+	}, extra...)
+	err := row.Scan(dest...)
+	return t, err
+}
+
+func queryTasks(q queryer, query string, args ...interface{}) ([]domain.Task, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		slog.Error("Failed to query tasks", "error", err)
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Failed to close rows", "error", err)
+		}
+	}()
+
+	var tasks []domain.Task
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			slog.Error("Failed to scan task row", "error", err)
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Error iterating rows", "error", err)
+		return nil, err
+	}
+	return tasks, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"prova-fattocs/internal/domain"
+	"strings"
+)
+
+// TaskEventRepository reads the audit log written by TaskRepository.
+type TaskEventRepository interface {
+	List(filter domain.AuditFilter) ([]domain.TaskEvent, int, error)
+}
+
+type PostgresTaskEventRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTaskEventRepository(db *sql.DB) TaskEventRepository {
+	slog.Info("Creating new PostgresTaskEventRepository")
+	return &PostgresTaskEventRepository{db: db}
+}
+
+// List returns the page of events selected by filter, newest first, together
+// with the number of events matching it across all pages.
+func (r *PostgresTaskEventRepository) List(filter domain.AuditFilter) ([]domain.TaskEvent, int, error) {
+	slog.Info("Listing task events", "task_id", filter.TaskID, "from", filter.From, "to", filter.To, "actor", filter.Actor)
+
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.TaskID != 0 {
+		add("task_id = $%d", filter.TaskID)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM task_events"+where, args...).Scan(&total); err != nil {
+		slog.Error("Failed to count task events", "error", err)
+		return nil, 0, err
+	}
+
+	query := "SELECT id, task_id, action, old_data, new_data, actor, request_id, created_at FROM task_events" +
+		where + " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit, filter.Offset())
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		slog.Error("Failed to query task events", "error", err)
+		return nil, 0, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Failed to close rows", "error", err)
+		}
+	}()
+
+	events := []domain.TaskEvent{}
+	for rows.Next() {
+		var e domain.TaskEvent
+		var oldData, newData []byte
+		if err := rows.Scan(&e.ID, &e.TaskID, &e.Action, &oldData, &newData, &e.Actor, &e.RequestID, &e.CreatedAt); err != nil {
+			slog.Error("Failed to scan task event row", "error", err)
+			return nil, 0, err
+		}
+		if e.Before, err = decodeTask(oldData); err != nil {
+			slog.Error("Failed to decode task event", "id", e.ID, "error", err)
+			return nil, 0, err
+		}
+		if e.After, err = decodeTask(newData); err != nil {
+			slog.Error("Failed to decode task event", "id", e.ID, "error", err)
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Error iterating rows", "error", err)
+		return nil, 0, err
+	}
+
+	slog.Info("Successfully listed task events", "count", len(events), "total", total)
+	return events, total, nil
+}
+
+// decodeTask reads a task snapshot stored as JSONB; NULL yields nil.
+func decodeTask(data []byte) (*domain.Task, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var t domain.Task
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
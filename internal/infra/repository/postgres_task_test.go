@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"prova-fattocs/internal/domain"
+)
+
+// openTestDB connects to the database in TEST_DATABASE_URL and recreates the
+// schema from migrations/database.sql. Point it at a disposable database: the
+// migration drops the tasks table.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping Postgres integration test")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := os.ReadFile("../../../migrations/database.sql")
+	if err != nil {
+		t.Fatalf("failed to read migration: %v", err)
+	}
+	if _, err := db.Exec(strings.TrimPrefix(string(schema), "\ufeff")); err != nil {
+		t.Fatalf("failed to apply migration: %v", err)
+	}
+	return db
+}
+
+func TestCreate_ConcurrentAllocatesDistinctOrders(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresTaskRepository(db)
+
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := repo.Create(domain.Task{Name: fmt.Sprintf("Task %d", i), Cost: 10_00, Deadline: domain.NewDate(2025, time.August, 10)})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("expected every create to succeed but got %v", err)
+		}
+	}
+
+	tasks, _, err := repo.List(domain.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list tasks: %v", err)
+	}
+	if len(tasks) != n {
+		t.Fatalf("expected %d tasks but got %d", n, len(tasks))
+	}
+	for i, task := range tasks {
+		if task.OrderNumber != i+1 {
+			t.Errorf("expected order %d but got %d", i+1, task.OrderNumber)
+		}
+	}
+}
+
+func TestCreate_ConcurrentDuplicateName(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresTaskRepository(db)
+
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Create(domain.Task{Name: "Same name", Cost: 10_00, Deadline: domain.NewDate(2025, time.August, 10)})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var succeeded int
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, domain.ErrDuplicateName):
+			t.Errorf("expected duplicate name error but got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("expected exactly one create to succeed but got %d", succeeded)
+	}
+}
+
+func TestDelete_TrashFreesNameUntilRestore(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresTaskRepository(db)
+
+	deadline := domain.NewDate(2025, time.August, 10)
+	first, err := repo.Create(domain.Task{Name: "Task", Cost: 10_00, Deadline: deadline})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	if err := repo.Delete(first.ID, 0); err != nil {
+		t.Fatalf("failed to delete task: %v", err)
+	}
+
+	if _, err := repo.GetByID(first.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected deleted task to be hidden but got %v", err)
+	}
+	if _, err := repo.Create(domain.Task{Name: "Task", Cost: 20_00, Deadline: deadline}); err != nil {
+		t.Fatalf("expected the name to be free after delete but got %v", err)
+	}
+	if _, err := repo.Restore(first.ID, 0); !errors.Is(err, domain.ErrDuplicateName) {
+		t.Errorf("expected duplicate name error on restore but got %v", err)
+	}
+
+	purged, err := repo.PurgeDeleted(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("failed to purge trash: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("expected 1 purged task but got %d", purged)
+	}
+}
+
+func TestAudit_RecordsEveryWrite(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresTaskRepository(db).WithAudit(domain.AuditInfo{Actor: "ana", RequestID: "req-1"})
+	events := NewPostgresTaskEventRepository(db)
+
+	created, err := repo.Create(domain.Task{Name: "Task", Cost: 10_00, Deadline: domain.NewDate(2025, time.August, 10)})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	cost := domain.Money(25_00)
+	if _, err := repo.Patch(created.ID, domain.TaskPatch{Cost: &cost}); err != nil {
+		t.Fatalf("failed to patch task: %v", err)
+	}
+	if err := repo.Delete(created.ID, 0); err != nil {
+		t.Fatalf("failed to delete task: %v", err)
+	}
+
+	history, total, err := events.List(domain.AuditFilter{TaskID: created.ID})
+	if err != nil {
+		t.Fatalf("failed to list events: %v", err)
+	}
+	if total != 3 {
+		t.Fatalf("expected 3 events but got %d", total)
+	}
+
+	patched := history[1]
+	if patched.Action != domain.ActionUpdated || patched.Actor != "ana" || patched.RequestID != "req-1" {
+		t.Errorf("unexpected event %+v", patched)
+	}
+	if patched.Before.Cost != 10_00 || patched.After.Cost != 25_00 {
+		t.Errorf("expected cost to go from 10.00 to 25.00 but got %v to %v", patched.Before.Cost, patched.After.Cost)
+	}
+
+	if _, err := db.Exec("DELETE FROM task_events"); err == nil {
+		t.Error("expected the audit log to reject deletes")
+	}
+}
+
+func TestRevert_RestoresSnapshotAndUndeletes(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresTaskRepository(db)
+
+	created, err := repo.Create(domain.Task{Name: "Task", Cost: 10_00, Deadline: domain.NewDate(2025, time.August, 10)})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	cost := domain.Money(99_00)
+	if _, err := repo.Patch(created.ID, domain.TaskPatch{Cost: &cost}); err != nil {
+		t.Fatalf("failed to patch task: %v", err)
+	}
+	if err := repo.Delete(created.ID, 0); err != nil {
+		t.Fatalf("failed to delete task: %v", err)
+	}
+
+	reverted, err := repo.Revert(created.ID, 1, 0)
+	if err != nil {
+		t.Fatalf("failed to revert task: %v", err)
+	}
+	if reverted.Cost != 10_00 || reverted.DeletedAt != nil || reverted.OrderNumber != 1 {
+		t.Errorf("expected the original, undeleted task but got %+v", reverted)
+	}
+
+	revisions, err := repo.ListRevisions(created.ID)
+	if err != nil {
+		t.Fatalf("failed to list revisions: %v", err)
+	}
+	if len(revisions) != 3 || revisions[0].Revision != 3 {
+		t.Errorf("expected the revert to be saved as revision 3 but got %+v", revisions)
+	}
+
+	if _, err := repo.Revert(created.ID, 9, 0); !errors.Is(err, domain.ErrRevisionNotFound) {
+		t.Errorf("expected revision not found error but got %v", err)
+	}
+}
+
+func TestTransaction_RollsBackEveryWrite(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresTaskRepository(db)
+
+	deadline := domain.NewDate(2025, time.August, 10)
+	err := repo.Transaction(func(tx TaskRepository) error {
+		if _, err := tx.Create(domain.Task{Name: "Task 1", Cost: 10_00, Deadline: deadline}); err != nil {
+			return err
+		}
+		_, err := tx.Create(domain.Task{Name: "Task 1", Cost: 10_00, Deadline: deadline})
+		return err
+	})
+	if !errors.Is(err, domain.ErrDuplicateName) {
+		t.Fatalf("expected duplicate name error but got %v", err)
+	}
+
+	tasks, _, err := repo.List(domain.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list tasks: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("expected the first create to be rolled back but got %+v", tasks)
+	}
+}
+
+func TestReorder_KeepsVersions(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresTaskRepository(db)
+
+	deadline := domain.NewDate(2025, time.August, 10)
+	var tasks []domain.Task
+	for i := 1; i <= 3; i++ {
+		task, err := repo.Create(domain.Task{Name: fmt.Sprintf("Task %d", i), Cost: 10_00, Deadline: deadline})
+		if err != nil {
+			t.Fatalf("failed to create task: %v", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := repo.Reorder(tasks[2].ID, 1, tasks[2].Version); err != nil {
+		t.Fatalf("failed to reorder task: %v", err)
+	}
+	if err := repo.Delete(tasks[0].ID, 0); err != nil {
+		t.Fatalf("failed to delete task: %v", err)
+	}
+
+	// Editors of the shifted task can still save with the version they read.
+	if _, err := repo.Update(tasks[1].ID, domain.Task{Name: "Task 2", Cost: 20_00, Deadline: deadline, Version: tasks[1].Version}); err != nil {
+		t.Errorf("expected the reorder not to change the version but got %v", err)
+	}
+}
+
+func TestReorderAll_StaleListVersion(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresTaskRepository(db)
+
+	deadline := domain.NewDate(2025, time.August, 10)
+	var ids []int64
+	for i := 1; i <= 3; i++ {
+		task, err := repo.Create(domain.Task{Name: fmt.Sprintf("Task %d", i), Cost: 10_00, Deadline: deadline})
+		if err != nil {
+			t.Fatalf("failed to create task: %v", err)
+		}
+		ids = append(ids, task.ID)
+	}
+
+	version, err := repo.ListVersion()
+	if err != nil {
+		t.Fatalf("failed to get list version: %v", err)
+	}
+
+	// Someone else moves a task after the list was read: moving does not
+	// change task versions, but it does change the list version.
+	if err := repo.Reorder(ids[2], 1, 0); err != nil {
+		t.Fatalf("failed to reorder task: %v", err)
+	}
+	if _, _, err := repo.ReorderAll([]int64{ids[1], ids[0], ids[2]}, version); !errors.Is(err, domain.ErrPreconditionFailed) {
+		t.Fatalf("expected precondition failed error but got %v", err)
+	}
+
+	current, err := repo.ListVersion()
+	if err != nil {
+		t.Fatalf("failed to get list version: %v", err)
+	}
+	tasks, next, err := repo.ReorderAll([]int64{ids[1], ids[0], ids[2]}, current)
+	if err != nil {
+		t.Fatalf("expected the current version to be accepted but got %v", err)
+	}
+	if tasks[0].ID != ids[1] {
+		t.Errorf("expected task %d first but got %+v", ids[1], tasks)
+	}
+	if next == current {
+		t.Error("expected the list version to change with the order")
+	}
+}
+
+func TestListAfter_WalksEveryTaskOnce(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresTaskRepository(db)
+
+	deadline := domain.NewDate(2025, time.August, 10)
+	for i := 1; i <= 7; i++ {
+		// Costs repeat so the id has to break ties between batches.
+		cost := domain.Money(i%3) * 10_00
+		if _, err := repo.Create(domain.Task{Name: fmt.Sprintf("Task %d", i), Cost: cost, Deadline: deadline}); err != nil {
+			t.Fatalf("failed to create task: %v", err)
+		}
+	}
+
+	opts := domain.ListOptions{Sort: "cost", Desc: true}
+	want, _, err := repo.List(opts)
+	if err != nil {
+		t.Fatalf("failed to list tasks: %v", err)
+	}
+
+	var got []domain.Task
+	var after *domain.Task
+	for {
+		batch, err := repo.ListAfter(opts, after, 2)
+		if err != nil {
+			t.Fatalf("failed to list tasks after cursor: %v", err)
+		}
+		got = append(got, batch...)
+		if len(batch) < 2 {
+			break
+		}
+		after = &batch[len(batch)-1]
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d tasks but got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID {
+			t.Errorf("position %d: expected task %d but got %d", i, want[i].ID, got[i].ID)
+		}
+	}
+}
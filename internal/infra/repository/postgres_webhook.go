@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"prova-fattocs/internal/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const webhookColumns = "id, url, events, secret, active, created_at, updated_at"
+
+const deliveryColumns = "id, webhook_id, event_id, event, payload, status, attempts, next_attempt_at, created_at"
+
+// WebhookRepository persists webhook subscriptions and the queue of
+// deliveries made to them.
+type WebhookRepository interface {
+	Create(webhook domain.Webhook) (domain.Webhook, error)
+	List() ([]domain.Webhook, error)
+	GetByID(id int64) (domain.Webhook, error)
+	// Update replaces a subscription, keeping its secret when the new one is
+	// empty.
+	Update(id int64, webhook domain.Webhook) (domain.Webhook, error)
+	// Delete removes a subscription and its deliveries.
+	Delete(id int64) error
+
+	// Enqueue queues an event for every active subscription asking for it and
+	// returns how many deliveries were queued. Subscriptions that already had
+	// the event queued are skipped, so enqueuing again is harmless.
+	Enqueue(eventID, event string, payload []byte) (int, error)
+	// ClaimDue returns up to limit pending deliveries whose next attempt is
+	// due, oldest first, with the URL and secret of their subscription. Their
+	// next attempt is pushed lease into the future so other dispatchers skip
+	// them while they are sent; if the sender dies they are retried after it.
+	ClaimDue(limit int, lease time.Duration) ([]domain.WebhookDelivery, error)
+	// RecordAttempt logs an attempt and moves the delivery to status, with
+	// its next attempt at next (nil when none is left).
+	RecordAttempt(id int64, attempt domain.DeliveryAttempt, status domain.DeliveryStatus, next *time.Time) error
+
+	ListDeliveries(webhookID int64, opts domain.ListOptions) ([]domain.WebhookDelivery, int, error)
+	// GetDelivery returns a delivery with its attempt log.
+	GetDelivery(webhookID, id int64) (domain.WebhookDelivery, error)
+	// Redeliver queues a new delivery of the same event, due immediately.
+	Redeliver(webhookID, id int64) (domain.WebhookDelivery, error)
+}
+
+type PostgresWebhookRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWebhookRepository(db *sql.DB) WebhookRepository {
+	slog.Info("Creating new PostgresWebhookRepository")
+	return &PostgresWebhookRepository{db: db}
+}
+
+func (r *PostgresWebhookRepository) Create(webhook domain.Webhook) (domain.Webhook, error) {
+	slog.Info("Creating webhook", "url", webhook.URL)
+
+	created, err := scanWebhook(r.db.QueryRow(
+		"INSERT INTO webhooks (url, events, secret, active) VALUES ($1, $2, $3, $4) RETURNING "+webhookColumns,
+		webhook.URL, eventsArray(webhook.Events), webhook.Secret, webhook.Active,
+	))
+	if err != nil {
+		slog.Error("Failed to create webhook", "error", err)
+		return domain.Webhook{}, err
+	}
+
+	slog.Info("Successfully created webhook", "id", created.ID)
+	return created, nil
+}
+
+func (r *PostgresWebhookRepository) List() ([]domain.Webhook, error) {
+	slog.Info("Listing webhooks")
+
+	rows, err := r.db.Query("SELECT " + webhookColumns + " FROM webhooks ORDER BY id")
+	if err != nil {
+		slog.Error("Failed to query webhooks", "error", err)
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Failed to close rows", "error", err)
+		}
+	}()
+
+	webhooks := []domain.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			slog.Error("Failed to scan webhook row", "error", err)
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Error iterating rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Successfully listed webhooks", "count", len(webhooks))
+	return webhooks, nil
+}
+
+func (r *PostgresWebhookRepository) GetByID(id int64) (domain.Webhook, error) {
+	slog.Info("Getting webhook by id", "id", id)
+
+	w, err := scanWebhook(r.db.QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE id=$1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Warn("Webhook not found", "id", id)
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	} else if err != nil {
+		slog.Error("Failed to get webhook", "id", id, "error", err)
+		return domain.Webhook{}, err
+	}
+
+	return w, nil
+}
+
+func (r *PostgresWebhookRepository) Update(id int64, webhook domain.Webhook) (domain.Webhook, error) {
+	slog.Info("Updating webhook", "id", id)
+
+	updated, err := scanWebhook(r.db.QueryRow(`
+		UPDATE webhooks
+		SET url=$2, events=$3, secret=COALESCE(NULLIF($4, ''), secret), active=$5, updated_at=now()
+		WHERE id=$1
+		RETURNING `+webhookColumns,
+		id, webhook.URL, eventsArray(webhook.Events), webhook.Secret, webhook.Active,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Warn("Webhook not found", "id", id)
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	} else if err != nil {
+		slog.Error("Failed to update webhook", "id", id, "error", err)
+		return domain.Webhook{}, err
+	}
+
+	slog.Info("Successfully updated webhook", "id", id)
+	return updated, nil
+}
+
+func (r *PostgresWebhookRepository) Delete(id int64) error {
+	slog.Info("Deleting webhook", "id", id)
+
+	result, err := r.db.Exec("DELETE FROM webhooks WHERE id=$1", id)
+	if err != nil {
+		slog.Error("Failed to delete webhook", "id", id, "error", err)
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		slog.Error("Failed to get rows affected", "error", err)
+		return err
+	} else if n == 0 {
+		slog.Warn("Webhook not found", "id", id)
+		return domain.ErrWebhookNotFound
+	}
+
+	slog.Info("Successfully deleted webhook", "id", id)
+	return nil
+}
+
+func (r *PostgresWebhookRepository) Enqueue(eventID, event string, payload []byte) (int, error) {
+	slog.Info("Enqueuing webhook deliveries", "event_id", eventID, "event", event)
+
+	result, err := r.db.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload)
+		SELECT id, $1::text, $2::text, $3::jsonb FROM webhooks
+		WHERE active AND (cardinality(events) = 0 OR $2::text = ANY(events))
+		ORDER BY id
+		ON CONFLICT (webhook_id, event_id) WHERE NOT redelivery DO NOTHING`,
+		eventID, event, string(payload),
+	)
+	if err != nil {
+		slog.Error("Failed to enqueue webhook deliveries", "event_id", eventID, "error", err)
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("Failed to get rows affected", "error", err)
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+func (r *PostgresWebhookRepository) ClaimDue(limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.Query(`
+		WITH due AS (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= now() AND w.active
+			ORDER BY d.next_attempt_at, d.id
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d
+			SET next_attempt_at = now() + $2::double precision * interval '1 millisecond'
+			FROM due WHERE d.id = due.id
+			RETURNING d.id, d.webhook_id, d.event_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at, d.created_at
+		)
+		SELECT c.id, c.webhook_id, c.event_id, c.event, c.payload, c.status, c.attempts, c.next_attempt_at, c.created_at, w.url, w.secret
+		FROM claimed c JOIN webhooks w ON w.id = c.webhook_id
+		ORDER BY c.id`,
+		limit, lease.Milliseconds(),
+	)
+	if err != nil {
+		slog.Error("Failed to claim webhook deliveries", "error", err)
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Failed to close rows", "error", err)
+		}
+	}()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		var url, secret string
+		d, err := scanDelivery(rows, &url, &secret)
+		if err != nil {
+			slog.Error("Failed to scan webhook delivery row", "error", err)
+			return nil, err
+		}
+		d.URL, d.Secret = url, secret
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Error iterating rows", "error", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *PostgresWebhookRepository) RecordAttempt(id int64, attempt domain.DeliveryAttempt, status domain.DeliveryStatus, next *time.Time) error {
+	slog.Info("Recording webhook delivery attempt", "id", id, "attempt", attempt.Attempt, "status", status)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.Error("Failed to roll back transaction", "error", err)
+		}
+	}()
+
+	if _, err := tx.Exec(`
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.DurationMS, attempt.AttemptedAt,
+	); err != nil {
+		slog.Error("Failed to insert webhook delivery attempt", "id", id, "error", err)
+		return err
+	}
+	if _, err := tx.Exec(
+		"UPDATE webhook_deliveries SET attempts=$2, status=$3, next_attempt_at=$4 WHERE id=$1",
+		id, attempt.Attempt, status, next,
+	); err != nil {
+		slog.Error("Failed to update webhook delivery", "id", id, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Failed to commit transaction", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresWebhookRepository) ListDeliveries(webhookID int64, opts domain.ListOptions) ([]domain.WebhookDelivery, int, error) {
+	slog.Info("Listing webhook deliveries", "webhook_id", webhookID)
+
+	var exists bool
+	var total int
+	if err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM webhooks WHERE id=$1),
+			(SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id=$1)`, webhookID,
+	).Scan(&exists, &total); err != nil {
+		slog.Error("Failed to count webhook deliveries", "webhook_id", webhookID, "error", err)
+		return nil, 0, err
+	}
+	if !exists {
+		slog.Warn("Webhook not found", "id", webhookID)
+		return nil, 0, domain.ErrWebhookNotFound
+	}
+
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries WHERE webhook_id=$1 ORDER BY id DESC"
+	args := []interface{}{webhookID}
+	if opts.Limit > 0 {
+		args = append(args, opts.Limit, opts.Offset())
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		slog.Error("Failed to query webhook deliveries", "webhook_id", webhookID, "error", err)
+		return nil, 0, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Failed to close rows", "error", err)
+		}
+	}()
+
+	deliveries := []domain.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			slog.Error("Failed to scan webhook delivery row", "error", err)
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Error iterating rows", "error", err)
+		return nil, 0, err
+	}
+
+	slog.Info("Successfully listed webhook deliveries", "webhook_id", webhookID, "count", len(deliveries), "total", total)
+	return deliveries, total, nil
+}
+
+func (r *PostgresWebhookRepository) GetDelivery(webhookID, id int64) (domain.WebhookDelivery, error) {
+	slog.Info("Getting webhook delivery", "webhook_id", webhookID, "id", id)
+
+	d, err := scanDelivery(r.db.QueryRow("SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id=$1 AND webhook_id=$2", id, webhookID))
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Warn("Webhook delivery not found", "webhook_id", webhookID, "id", id)
+		return domain.WebhookDelivery{}, domain.ErrDeliveryNotFound
+	} else if err != nil {
+		slog.Error("Failed to get webhook delivery", "id", id, "error", err)
+		return domain.WebhookDelivery{}, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT attempt, status_code, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts WHERE delivery_id=$1 ORDER BY attempt`, id)
+	if err != nil {
+		slog.Error("Failed to query webhook delivery attempts", "id", id, "error", err)
+		return domain.WebhookDelivery{}, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Failed to close rows", "error", err)
+		}
+	}()
+
+	d.AttemptLog = []domain.DeliveryAttempt{}
+	for rows.Next() {
+		var a domain.DeliveryAttempt
+		if err := rows.Scan(&a.Attempt, &a.StatusCode, &a.Error, &a.DurationMS, &a.AttemptedAt); err != nil {
+			slog.Error("Failed to scan webhook delivery attempt row", "error", err)
+			return domain.WebhookDelivery{}, err
+		}
+		d.AttemptLog = append(d.AttemptLog, a)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Error iterating rows", "error", err)
+		return domain.WebhookDelivery{}, err
+	}
+
+	return d, nil
+}
+
+func (r *PostgresWebhookRepository) Redeliver(webhookID, id int64) (domain.WebhookDelivery, error) {
+	slog.Info("Redelivering webhook delivery", "webhook_id", webhookID, "id", id)
+
+	d, err := scanDelivery(r.db.QueryRow(`
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload, redelivery)
+		SELECT webhook_id, event_id, event, payload, TRUE FROM webhook_deliveries WHERE id=$1 AND webhook_id=$2
+		RETURNING `+deliveryColumns, id, webhookID))
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Warn("Webhook delivery not found", "webhook_id", webhookID, "id", id)
+		return domain.WebhookDelivery{}, domain.ErrDeliveryNotFound
+	} else if err != nil {
+		slog.Error("Failed to redeliver webhook delivery", "id", id, "error", err)
+		return domain.WebhookDelivery{}, err
+	}
+
+	slog.Info("Successfully queued redelivery", "id", id, "redelivery_id", d.ID)
+	return d, nil
+}
+
+// eventsArray stores a nil list of events as an empty array, not NULL.
+func eventsArray(events []string) interface{} {
+	if events == nil {
+		events = []string{}
+	}
+	return pq.Array(events)
+}
+
+func scanWebhook(row scanner) (domain.Webhook, error) {
+	var w domain.Webhook
+	err := row.Scan(&w.ID, &w.URL, pq.Array(&w.Events), &w.Secret, &w.Active, &w.CreatedAt, &w.UpdatedAt)
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+	return w, err
+}
+
+// scanDelivery reads the deliveryColumns of a row, followed by any extra
+// columns the query selects.
+func scanDelivery(row scanner, extra ...interface{}) (domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	var payload []byte
+	dest := append([]interface{}{
+		&d.ID, &d.WebhookID, &d.EventID, &d.Event, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	d.Payload = payload
+	return d, nil
+}
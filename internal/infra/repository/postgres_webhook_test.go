@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"prova-fattocs/internal/domain"
+)
+
+func TestWebhookQueue_EnqueueClaimAndRedeliver(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresWebhookRepository(db)
+
+	all, err := repo.Create(domain.Webhook{URL: "http://localhost/all", Events: []string{}, Secret: "0123456789abcdef", Active: true})
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	if _, err := repo.Create(domain.Webhook{URL: "http://localhost/deleted", Events: []string{"task.deleted"}, Secret: "0123456789abcdef", Active: true}); err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	if _, err := repo.Create(domain.Webhook{URL: "http://localhost/off", Secret: "0123456789abcdef", Active: false}); err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+
+	n, err := repo.Enqueue("1", "task.created", []byte(`{"id":"1"}`))
+	if err != nil {
+		t.Fatalf("failed to enqueue: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected only the catch-all subscription to get the event but got %d deliveries", n)
+	}
+
+	claimed, err := repo.ClaimDue(10, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to claim: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].WebhookID != all.ID || claimed[0].URL != all.URL || claimed[0].Secret != all.Secret {
+		t.Fatalf("expected the delivery with its subscription but got %+v", claimed)
+	}
+	if again, err := repo.ClaimDue(10, time.Minute); err != nil || len(again) != 0 {
+		t.Fatalf("expected a claimed delivery to be leased but got %+v (%v)", again, err)
+	}
+
+	d := claimed[0]
+	attempt := domain.DeliveryAttempt{Attempt: 1, StatusCode: 500, Error: "receiver responded 500", DurationMS: 3, AttemptedAt: time.Now()}
+	if err := repo.RecordAttempt(d.ID, attempt, domain.DeliveryFailed, nil); err != nil {
+		t.Fatalf("failed to record attempt: %v", err)
+	}
+
+	logged, err := repo.GetDelivery(all.ID, d.ID)
+	if err != nil {
+		t.Fatalf("failed to get delivery: %v", err)
+	}
+	if logged.Status != domain.DeliveryFailed || logged.Attempts != 1 || len(logged.AttemptLog) != 1 || logged.AttemptLog[0].StatusCode != 500 {
+		t.Errorf("expected one failed attempt to be logged but got %+v", logged)
+	}
+
+	redelivery, err := repo.Redeliver(all.ID, d.ID)
+	if err != nil {
+		t.Fatalf("failed to redeliver: %v", err)
+	}
+	if redelivery.ID == d.ID || redelivery.EventID != d.EventID || redelivery.Status != domain.DeliveryPending {
+		t.Errorf("expected a new pending delivery of the same event but got %+v", redelivery)
+	}
+
+	deliveries, total, err := repo.ListDeliveries(all.ID, domain.ListOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to list deliveries: %v", err)
+	}
+	if total != 2 || len(deliveries) != 2 || deliveries[0].ID != redelivery.ID {
+		t.Errorf("expected both deliveries, newest first, but got %d of %d", len(deliveries), total)
+	}
+
+	if _, err := repo.Redeliver(all.ID+100, d.ID); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Errorf("expected ErrDeliveryNotFound for another webhook but got %v", err)
+	}
+	if err := repo.Delete(all.ID); err != nil {
+		t.Fatalf("failed to delete webhook: %v", err)
+	}
+	if _, _, err := repo.ListDeliveries(all.ID, domain.ListOptions{}); !errors.Is(err, domain.ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound after deleting but got %v", err)
+	}
+}
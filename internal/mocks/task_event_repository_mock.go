@@ -0,0 +1,11 @@
+package mocks
+
+import "prova-fattocs/internal/domain"
+
+type TaskEventRepositoryMock struct {
+	ListFunc func(filter domain.AuditFilter) ([]domain.TaskEvent, int, error)
+}
+
+func (m *TaskEventRepositoryMock) List(filter domain.AuditFilter) ([]domain.TaskEvent, int, error) {
+	return m.ListFunc(filter)
+}
@@ -1,36 +1,125 @@
 package mocks
 
-import "prova-fattocs/internal/domain"
+import (
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+	"time"
+)
 
 type TaskRepositoryMock struct {
-	ListFunc         func() ([]domain.Task, error)
-	CreateFunc       func(task domain.Task) error
-	UpdateFunc       func(id int64, task domain.Task) error
-	DeleteFunc       func(id int64) error
-	ReorderFunc      func(id int64, direction int64) error
-	ExistsByNameFunc func(name string, id int64) (bool, error)
+	WithAuditFunc     func(info domain.AuditInfo) repository.TaskRepository
+	TransactionFunc   func(fn func(repo repository.TaskRepository) error) error
+	ListFunc          func(opts domain.ListOptions) ([]domain.Task, int, error)
+	ListAfterFunc     func(opts domain.ListOptions, after *domain.Task, limit int) ([]domain.Task, error)
+	GetByNameFunc     func(name string) (domain.Task, error)
+	GetByIDFunc       func(id int64) (domain.Task, error)
+	SearchFunc        func(query string, limit int) ([]domain.SearchResult, error)
+	CreateFunc        func(task domain.Task) (domain.Task, error)
+	UpdateFunc        func(id int64, task domain.Task) (domain.Task, error)
+	PatchFunc         func(id int64, patch domain.TaskPatch) (domain.Task, error)
+	UpdateStatusFunc  func(id int64, from, to domain.Status, version int64) (domain.Task, error)
+	DeleteFunc        func(id int64, version int64) error
+	ListDeletedFunc   func() ([]domain.Task, error)
+	RestoreFunc       func(id int64, version int64) (domain.Task, error)
+	PurgeDeletedFunc  func(before time.Time) (int64, error)
+	ListRevisionsFunc func(id int64) ([]domain.TaskRevision, error)
+	GetRevisionFunc   func(id int64, revision int) (domain.TaskRevision, error)
+	RevertFunc        func(id int64, revision int, version int64) (domain.Task, error)
+	ReorderFunc       func(id int64, position int64, version int64) error
+	ReorderAllFunc    func(ids []int64, version string) ([]domain.Task, string, error)
+	ExistsByNameFunc  func(name string, id int64) (bool, error)
+	LastChangeFunc    func() (int64, time.Time, error)
+	ListVersionFunc   func() (string, error)
 }
 
-func (m *TaskRepositoryMock) List() ([]domain.Task, error) {
-	return m.ListFunc()
+func (m *TaskRepositoryMock) WithAudit(info domain.AuditInfo) repository.TaskRepository {
+	return m.WithAuditFunc(info)
 }
 
-func (m *TaskRepositoryMock) Create(task domain.Task) error {
+func (m *TaskRepositoryMock) Transaction(fn func(repo repository.TaskRepository) error) error {
+	return m.TransactionFunc(fn)
+}
+
+func (m *TaskRepositoryMock) List(opts domain.ListOptions) ([]domain.Task, int, error) {
+	return m.ListFunc(opts)
+}
+
+func (m *TaskRepositoryMock) ListAfter(opts domain.ListOptions, after *domain.Task, limit int) ([]domain.Task, error) {
+	return m.ListAfterFunc(opts, after, limit)
+}
+
+func (m *TaskRepositoryMock) GetByID(id int64) (domain.Task, error) {
+	return m.GetByIDFunc(id)
+}
+
+func (m *TaskRepositoryMock) GetByName(name string) (domain.Task, error) {
+	return m.GetByNameFunc(name)
+}
+
+func (m *TaskRepositoryMock) Search(query string, limit int) ([]domain.SearchResult, error) {
+	return m.SearchFunc(query, limit)
+}
+
+func (m *TaskRepositoryMock) Create(task domain.Task) (domain.Task, error) {
 	return m.CreateFunc(task)
 }
 
-func (m *TaskRepositoryMock) Update(id int64, task domain.Task) error {
+func (m *TaskRepositoryMock) Update(id int64, task domain.Task) (domain.Task, error) {
 	return m.UpdateFunc(id, task)
 }
 
-func (m *TaskRepositoryMock) Delete(id int64) error {
-	return m.DeleteFunc(id)
+func (m *TaskRepositoryMock) Patch(id int64, patch domain.TaskPatch) (domain.Task, error) {
+	return m.PatchFunc(id, patch)
+}
+
+func (m *TaskRepositoryMock) UpdateStatus(id int64, from, to domain.Status, version int64) (domain.Task, error) {
+	return m.UpdateStatusFunc(id, from, to, version)
+}
+
+func (m *TaskRepositoryMock) Delete(id int64, version int64) error {
+	return m.DeleteFunc(id, version)
+}
+
+func (m *TaskRepositoryMock) ListDeleted() ([]domain.Task, error) {
+	return m.ListDeletedFunc()
+}
+
+func (m *TaskRepositoryMock) Restore(id int64, version int64) (domain.Task, error) {
+	return m.RestoreFunc(id, version)
+}
+
+func (m *TaskRepositoryMock) PurgeDeleted(before time.Time) (int64, error) {
+	return m.PurgeDeletedFunc(before)
+}
+
+func (m *TaskRepositoryMock) ListRevisions(id int64) ([]domain.TaskRevision, error) {
+	return m.ListRevisionsFunc(id)
+}
+
+func (m *TaskRepositoryMock) GetRevision(id int64, revision int) (domain.TaskRevision, error) {
+	return m.GetRevisionFunc(id, revision)
 }
 
-func (m *TaskRepositoryMock) Reorder(id int64, direction int64) error {
-	return m.ReorderFunc(id, direction)
+func (m *TaskRepositoryMock) Revert(id int64, revision int, version int64) (domain.Task, error) {
+	return m.RevertFunc(id, revision, version)
+}
+
+func (m *TaskRepositoryMock) Reorder(id int64, position int64, version int64) error {
+	return m.ReorderFunc(id, position, version)
+}
+
+func (m *TaskRepositoryMock) ReorderAll(ids []int64, version string) ([]domain.Task, string, error) {
+	return m.ReorderAllFunc(ids, version)
 }
 
 func (m *TaskRepositoryMock) ExistsByName(name string, id int64) (bool, error) {
 	return m.ExistsByNameFunc(name, id)
 }
+
+func (m *TaskRepositoryMock) LastChange() (int64, time.Time, error) {
+	return m.LastChangeFunc()
+}
+
+func (m *TaskRepositoryMock) ListVersion() (string, error) {
+	return m.ListVersionFunc()
+}
@@ -0,0 +1,64 @@
+package mocks
+
+import (
+	"prova-fattocs/internal/domain"
+	"time"
+)
+
+type WebhookRepositoryMock struct {
+	CreateFunc         func(webhook domain.Webhook) (domain.Webhook, error)
+	ListFunc           func() ([]domain.Webhook, error)
+	GetByIDFunc        func(id int64) (domain.Webhook, error)
+	UpdateFunc         func(id int64, webhook domain.Webhook) (domain.Webhook, error)
+	DeleteFunc         func(id int64) error
+	EnqueueFunc        func(eventID, event string, payload []byte) (int, error)
+	ClaimDueFunc       func(limit int, lease time.Duration) ([]domain.WebhookDelivery, error)
+	RecordAttemptFunc  func(id int64, attempt domain.DeliveryAttempt, status domain.DeliveryStatus, next *time.Time) error
+	ListDeliveriesFunc func(webhookID int64, opts domain.ListOptions) ([]domain.WebhookDelivery, int, error)
+	GetDeliveryFunc    func(webhookID, id int64) (domain.WebhookDelivery, error)
+	RedeliverFunc      func(webhookID, id int64) (domain.WebhookDelivery, error)
+}
+
+func (m *WebhookRepositoryMock) Create(webhook domain.Webhook) (domain.Webhook, error) {
+	return m.CreateFunc(webhook)
+}
+
+func (m *WebhookRepositoryMock) List() ([]domain.Webhook, error) {
+	return m.ListFunc()
+}
+
+func (m *WebhookRepositoryMock) GetByID(id int64) (domain.Webhook, error) {
+	return m.GetByIDFunc(id)
+}
+
+func (m *WebhookRepositoryMock) Update(id int64, webhook domain.Webhook) (domain.Webhook, error) {
+	return m.UpdateFunc(id, webhook)
+}
+
+func (m *WebhookRepositoryMock) Delete(id int64) error {
+	return m.DeleteFunc(id)
+}
+
+func (m *WebhookRepositoryMock) Enqueue(eventID, event string, payload []byte) (int, error) {
+	return m.EnqueueFunc(eventID, event, payload)
+}
+
+func (m *WebhookRepositoryMock) ClaimDue(limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	return m.ClaimDueFunc(limit, lease)
+}
+
+func (m *WebhookRepositoryMock) RecordAttempt(id int64, attempt domain.DeliveryAttempt, status domain.DeliveryStatus, next *time.Time) error {
+	return m.RecordAttemptFunc(id, attempt, status, next)
+}
+
+func (m *WebhookRepositoryMock) ListDeliveries(webhookID int64, opts domain.ListOptions) ([]domain.WebhookDelivery, int, error) {
+	return m.ListDeliveriesFunc(webhookID, opts)
+}
+
+func (m *WebhookRepositoryMock) GetDelivery(webhookID, id int64) (domain.WebhookDelivery, error) {
+	return m.GetDeliveryFunc(webhookID, id)
+}
+
+func (m *WebhookRepositoryMock) Redeliver(webhookID, id int64) (domain.WebhookDelivery, error) {
+	return m.RedeliverFunc(webhookID, id)
+}
@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"prova-fattocs/internal/domain"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	actorHeader     = "X-Actor"
+
+	requestIDKey = "request_id"
+
+	// maxHeaderValue bounds the client-supplied values stored in the audit
+	// log.
+	maxHeaderValue = 255
+)
+
+// RequestID tags every request with an ID, reusing the one sent in
+// X-Request-ID when it is usable, and echoes it in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.GetHeader(requestIDHeader))
+		if id == "" || len(id) > maxHeaderValue {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// auditInfo attributes a change to the actor named in X-Actor. There is no
+// authentication yet, so the header is taken at face value.
+func auditInfo(c *gin.Context) domain.AuditInfo {
+	actor := strings.TrimSpace(c.GetHeader(actorHeader))
+	if actor == "" {
+		actor = "anonymous"
+	}
+	if len(actor) > maxHeaderValue {
+		actor = strings.ToValidUTF8(actor[:maxHeaderValue], "")
+	}
+	return domain.AuditInfo{Actor: actor, RequestID: c.GetString(requestIDKey)}
+}
@@ -0,0 +1,93 @@
+package routes
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"prova-fattocs/internal/app"
+	"prova-fattocs/internal/dto"
+	"prova-fattocs/pkg/response"
+)
+
+func SetupAuditRoutes(r *gin.Engine, auditService *app.AuditService) {
+	// Task history
+	// @Summary      Task history
+	// @Description  Returns the changes made to a task, newest first, with the values before and after each one
+	// @Tags         Audit
+	// @Produce      json
+	// @Param        id path int true "Task ID"
+	// @Param        from query string false "Only events at or after this RFC 3339 timestamp"
+	// @Param        to query string false "Only events before this RFC 3339 timestamp"
+	// @Param        actor query string false "Only events by this actor"
+	// @Param        limit query int false "Page size (default 100, max 500)"
+	// @Param        page query int false "Page number, starting at 1"
+	// @Success      200 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/{id}/history [get]
+	r.GET("/tasks/:id/history", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid ID", nil)
+			return
+		}
+
+		var query dto.AuditQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.BadRequest(c, "Invalid query parameters", nil)
+			return
+		}
+
+		filter, err := query.ToFilter()
+		if err != nil {
+			response.Error(c, err, "Invalid query parameters")
+			return
+		}
+
+		events, pagination, err := auditService.History(id, filter)
+		if err != nil {
+			response.Error(c, err, "Failed to fetch task history")
+			return
+		}
+
+		response.OKWithMeta(c, "Task history retrieved successfully", events, pagination)
+	})
+
+	// Audit log
+	// @Summary      Audit log
+	// @Description  Returns the changes made to every task, newest first, with the values before and after each one
+	// @Tags         Audit
+	// @Produce      json
+	// @Param        from query string false "Only events at or after this RFC 3339 timestamp"
+	// @Param        to query string false "Only events before this RFC 3339 timestamp"
+	// @Param        actor query string false "Only events by this actor"
+	// @Param        limit query int false "Page size (default 100, max 500)"
+	// @Param        page query int false "Page number, starting at 1"
+	// @Success      200 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /audit [get]
+	r.GET("/audit", func(c *gin.Context) {
+		var query dto.AuditQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.BadRequest(c, "Invalid query parameters", nil)
+			return
+		}
+
+		filter, err := query.ToFilter()
+		if err != nil {
+			response.Error(c, err, "Invalid query parameters")
+			return
+		}
+
+		events, pagination, err := auditService.List(filter)
+		if err != nil {
+			response.Error(c, err, "Failed to fetch audit log")
+			return
+		}
+
+		response.OKWithMeta(c, "Audit log retrieved successfully", events, pagination)
+	})
+}
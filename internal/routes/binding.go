@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/pkg/response"
+)
+
+func init() {
+	// Report validation errors with the JSON field names clients send
+	// instead of the Go struct field names.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// bindJSON decodes the request body into obj. Malformed payloads get a 400
+// and binding tag violations a 422 listing each offending field. It reports
+// whether the handler may continue.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]domain.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, domain.FieldError{
+				Field:   fe.Field(),
+				Code:    fe.Tag(),
+				Message: fe.Error(),
+			})
+		}
+		response.Error(c, domain.NewValidationError(fields...), "Invalid input")
+		return false
+	}
+
+	response.BadRequest(c, "Invalid input", nil)
+	return false
+}
@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+	"prova-fattocs/internal/mocks"
+)
+
+// bulkItem is an item of the bulk response with its field errors decoded.
+type bulkItem struct {
+	Index   int                 `json:"index"`
+	Action  string              `json:"action"`
+	ID      int64               `json:"id"`
+	Status  int                 `json:"status"`
+	Message string              `json:"message"`
+	Errors  []domain.FieldError `json:"errors"`
+}
+
+func newBulkRepo() *mocks.TaskRepositoryMock {
+	var m *mocks.TaskRepositoryMock
+	m = &mocks.TaskRepositoryMock{
+		TransactionFunc: func(fn func(repo repository.TaskRepository) error) error {
+			return fn(m)
+		},
+		ExistsByNameFunc: func(name string, id int64) (bool, error) {
+			return name == "Existing", nil
+		},
+		CreateFunc: func(task domain.Task) (domain.Task, error) {
+			task.ID = 11
+			return task, nil
+		},
+		DeleteFunc: func(id, version int64) error {
+			return domain.ErrNotFound
+		},
+	}
+	return m
+}
+
+const bulkBody = `{"mode":%q,"operations":[
+	{"action":"create","task":{"name":"Nova","cost":"10.00","deadline":"2025-08-10"}},
+	{"action":"create","task":{"name":"Sem custo","deadline":"2025-08-10"}},
+	{"action":"delete","id":404}
+]}`
+
+func bulkItems(t *testing.T, data json.RawMessage) []bulkItem {
+	t.Helper()
+	var items []bulkItem
+	if err := json.Unmarshal(data, &items); err != nil || len(items) != 3 {
+		t.Fatalf("expected 3 items but got %s: %v", data, err)
+	}
+	return items
+}
+
+func TestBulk_BestEffortMultiStatus(t *testing.T) {
+	r := newTestRouter(newBulkRepo(), nil)
+
+	w := serve(r, http.MethodPost, "/tasks/bulk", fmt.Sprintf(bulkBody, "best_effort"), nil)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207 but got %d: %s", w.Code, w.Body.String())
+	}
+	items := bulkItems(t, decode(t, w).Data)
+
+	if items[0].Status != http.StatusCreated || items[0].ID != 11 {
+		t.Errorf("expected the first task to be created but got %+v", items[0])
+	}
+	if items[1].Status != http.StatusUnprocessableEntity || len(items[1].Errors) != 1 || items[1].Errors[0].Field != "cost" {
+		t.Errorf("expected a 422 naming the cost field but got %+v", items[1])
+	}
+	if items[2].Status != http.StatusNotFound {
+		t.Errorf("expected the delete to report 404 but got %+v", items[2])
+	}
+}
+
+func TestBulk_AtomicReportsFirstFailure(t *testing.T) {
+	r := newTestRouter(newBulkRepo(), nil)
+
+	w := serve(r, http.MethodPost, "/tasks/bulk", fmt.Sprintf(bulkBody, "atomic"), nil)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected the status of the first failure but got %d: %s", w.Code, w.Body.String())
+	}
+	items := bulkItems(t, decode(t, w).Data)
+
+	if items[0].Status != http.StatusFailedDependency || items[2].Status != http.StatusFailedDependency {
+		t.Errorf("expected the other operations to be aborted with 424 but got %+v", items)
+	}
+	if items[1].Status != http.StatusUnprocessableEntity {
+		t.Errorf("expected the invalid create to report 422 but got %+v", items[1])
+	}
+}
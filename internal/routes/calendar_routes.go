@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"prova-fattocs/internal/app"
+	"prova-fattocs/internal/dto"
+	"prova-fattocs/pkg/response"
+)
+
+func setupCalendarRoutes(r *gin.Engine, taskService *app.TaskService) {
+	// Calendar feed of task deadlines
+	// @Summary      Calendar feed
+	// @Description  RFC 5545 calendar with one all-day event per task deadline. Accepts the filters of GET /tasks; pagination is ignored. Supports If-None-Match and If-Modified-Since so subscribers can poll cheaply.
+	// @Tags         Tasks
+	// @Produce      text/calendar
+	// @Param        status query []string false "Only tasks in these statuses (repeat or comma-separate)"
+	// @Param        deadline_after query string false "Only deadlines after this date (YYYY-MM-DD)"
+	// @Param        deadline_before query string false "Only deadlines before this date (YYYY-MM-DD)"
+	// @Param        name_contains query string false "Only tasks whose name contains this text"
+	// @Param        If-None-Match header string false "ETag of a previously fetched feed"
+	// @Param        If-Modified-Since header string false "Last-Modified of a previously fetched feed"
+	// @Success      200 {string} string "iCalendar file"
+	// @Success      304
+	// @Header       200 {string} ETag "Version of the feed"
+	// @Header       200 {string} Last-Modified "Time of the latest change to any task"
+	// @Failure      400 {object} response.Response
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/calendar.ics [get]
+	r.GET("/tasks/calendar.ics", func(c *gin.Context) {
+		var query dto.ListTasksQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.BadRequest(c, "Invalid query parameters", nil)
+			return
+		}
+
+		opts, err := query.ToOptions()
+		if err != nil {
+			response.Error(c, err, "Invalid query parameters")
+			return
+		}
+
+		// Every change to a task is logged, so the latest log entry and the
+		// filters determine the feed without building it.
+		changeID, changedAt, err := taskService.LastChange()
+		if err != nil {
+			response.Error(c, err, "Failed to build calendar")
+			return
+		}
+		filters := fnv.New32a()
+		filters.Write([]byte(c.Request.URL.Query().Encode()))
+		etag := fmt.Sprintf(`"%d-%08x"`, changeID, filters.Sum32())
+		stamp := changedAt.UTC().Truncate(time.Second)
+
+		// The validators are only sent once the feed is known to build, so
+		// an error response never carries a tag clients could cache against.
+		validators := http.Header{}
+		validators.Set("ETag", etag)
+		validators.Set("Cache-Control", "no-cache")
+		if !stamp.IsZero() {
+			validators.Set("Last-Modified", stamp.Format(http.TimeFormat))
+		} else {
+			stamp = time.Unix(0, 0).UTC()
+		}
+		if notModified(c, etag, stamp) {
+			for key, values := range validators {
+				c.Writer.Header()[key] = values
+			}
+			c.Status(http.StatusNotModified)
+			return
+		}
+
+		w := &deferredWriter{c: c, contentType: "text/calendar; charset=utf-8", disposition: `inline; filename="tasks.ics"`, header: validators}
+		if err := taskService.ExportCalendar(w, opts, stamp); err != nil {
+			if w.started {
+				slog.Error("Failed to stream calendar", "error", err)
+				return
+			}
+			response.Error(c, err, "Failed to build calendar")
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/mocks"
+)
+
+func calendarRepo(listErr error) *mocks.TaskRepositoryMock {
+	return &mocks.TaskRepositoryMock{
+		LastChangeFunc: func() (int64, time.Time, error) {
+			return 42, time.Date(2025, time.August, 1, 12, 30, 0, 0, time.UTC), nil
+		},
+		ListFunc: func(opts domain.ListOptions) ([]domain.Task, int, error) {
+			if listErr != nil {
+				return nil, 0, listErr
+			}
+			return []domain.Task{{ID: 7, Name: "Revisar contrato", Deadline: domain.NewDate(2025, time.December, 31), Version: 1}}, 1, nil
+		},
+	}
+}
+
+func TestCalendar_SendsValidators(t *testing.T) {
+	r := newTestRouter(calendarRepo(nil), nil)
+
+	w := serve(r, http.MethodGet, "/tasks/calendar.ics", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 but got %d: %s", w.Code, w.Body.String())
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	if got := w.Header().Get("Last-Modified"); got != "Fri, 01 Aug 2025 12:30:00 GMT" {
+		t.Errorf("expected Last-Modified of the latest change but got %q", got)
+	}
+
+	w = serve(r, http.MethodGet, "/tasks/calendar.ics", "", http.Header{"If-None-Match": {etag}})
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 but got %d", w.Code)
+	}
+	if got := w.Header().Get("ETag"); got != etag {
+		t.Errorf("expected the 304 to repeat ETag %s but got %q", etag, got)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected an empty body but got %q", w.Body.String())
+	}
+
+	w = serve(r, http.MethodGet, "/tasks/calendar.ics?status=done", "", http.Header{"If-None-Match": {etag}})
+	if w.Code != http.StatusOK {
+		t.Errorf("expected other filters to miss the tag but got %d", w.Code)
+	}
+}
+
+func TestCalendar_FailureSendsNoValidators(t *testing.T) {
+	r := newTestRouter(calendarRepo(errors.New("connection reset")), nil)
+
+	w := serve(r, http.MethodGet, "/tasks/calendar.ics", "", nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 but got %d", w.Code)
+	}
+	for _, name := range []string{"ETag", "Last-Modified", "Cache-Control"} {
+		if got := w.Header().Get(name); got != "" {
+			t.Errorf("expected no %s on a failed feed but got %q", name, got)
+		}
+	}
+	if body := decode(t, w); body.Message != "Failed to build calendar" {
+		t.Errorf("expected the fallback message but got %q", body.Message)
+	}
+}
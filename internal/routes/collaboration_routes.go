@@ -0,0 +1,133 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"prova-fattocs/internal/app"
+	"prova-fattocs/internal/domain"
+)
+
+// lockTokenHeader carries the token of the collaboration session whose locks
+// a write may override.
+const lockTokenHeader = "X-Lock-Token"
+
+// SetupCollaborationRoutes registers the collaboration WebSocket. Browsers
+// cannot set headers on WebSocket requests, so the user may also be given in
+// the actor query parameter.
+func SetupCollaborationRoutes(r *gin.Engine, taskService *app.TaskService, collab *app.Collaboration) {
+	// Collaboration channel
+	// @Summary      Collaboration channel
+	// @Description  WebSocket carrying presence, soft edit locks and task changes. Clients send {"type":"lock"|"unlock","task_id":N} and {"type":"ping"}; the server sends presence, locked, unlocked, lock_denied, change, pong and error messages. Locks belong to the connection, not the user name: the locked message carries a token that writes must send in X-Lock-Token, otherwise every write to the task (edits, transitions, deletion, restore, revert, moves, bulk operations and imports) fails with 423. Locks expire unless renewed by locking again, and are released when the connection closes.
+	// @Tags         Collaboration
+	// @Param        actor query string false "User name, for clients that cannot set X-Actor"
+	// @Success      101 {string} string "Switching Protocols"
+	// @Router       /ws [get]
+	r.GET("/ws", func(c *gin.Context) {
+		if actor := strings.TrimSpace(c.Query("actor")); actor != "" {
+			c.Request.Header.Set(actorHeader, actor)
+		}
+		user := auditInfo(c).Actor
+
+		// Handshake is left unset so any origin may connect, matching the
+		// CORS policy of the HTTP API.
+		server := websocket.Server{Handler: func(ws *websocket.Conn) {
+			serveCollaboration(ws, taskService, collab.Join(user))
+		}}
+		server.ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+// serveCollaboration relays the session's messages and task changes to ws
+// while handling the client's messages, until either side goes away.
+func serveCollaboration(ws *websocket.Conn, taskService *app.TaskService, session *app.Session) {
+	_, changes, _, cancel := taskService.Subscribe(0, false)
+	defer cancel()
+	defer session.Leave()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer ws.Close()
+		for {
+			var msg domain.CollabMessage
+			select {
+			case m, ok := <-session.Messages():
+				if !ok {
+					return
+				}
+				msg = m
+			case change, ok := <-changes:
+				if !ok {
+					return
+				}
+				msg = domain.CollabMessage{Type: domain.CollabChange, Change: &change}
+			}
+			if err := websocket.JSON.Send(ws, msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		var msg domain.CollabMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			// A frame that is not valid JSON has still been consumed.
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				session.Reply(domain.CollabMessage{Type: domain.CollabError, Message: "invalid message"})
+				continue
+			}
+			break
+		}
+		handleCollabMessage(taskService, session, msg)
+	}
+
+	session.Leave()
+	<-done
+}
+
+func handleCollabMessage(taskService *app.TaskService, session *app.Session, msg domain.CollabMessage) {
+	switch msg.Type {
+	case domain.CollabLock:
+		if _, err := taskService.GetByID(msg.TaskID); err != nil {
+			session.Reply(domain.CollabMessage{Type: domain.CollabError, TaskID: msg.TaskID, Message: collabErrorMessage(err)})
+			return
+		}
+		lock, err := session.Lock(msg.TaskID)
+		var lockedErr *domain.LockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			session.Reply(domain.CollabMessage{Type: domain.CollabLockDenied, TaskID: msg.TaskID, Lock: &lockedErr.Lock, Message: domain.ErrLocked.Error()})
+		case err != nil:
+			session.Reply(domain.CollabMessage{Type: domain.CollabError, TaskID: msg.TaskID, Message: collabErrorMessage(err)})
+		default:
+			session.Reply(domain.CollabMessage{Type: domain.CollabLocked, TaskID: msg.TaskID, Lock: &lock})
+		}
+	case domain.CollabUnlock:
+		session.Unlock(msg.TaskID)
+		session.Reply(domain.CollabMessage{Type: domain.CollabUnlocked, TaskID: msg.TaskID})
+	case domain.CollabPing:
+		session.Reply(domain.CollabMessage{Type: domain.CollabPong})
+	default:
+		session.Reply(domain.CollabMessage{Type: domain.CollabError, Message: fmt.Sprintf("unknown message type %q", msg.Type)})
+	}
+}
+
+// collabErrorMessage hides errors that are not part of the domain, as
+// response.Error does.
+func collabErrorMessage(err error) string {
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrNotFound.Error()
+	}
+	return "request failed"
+}
+
+func lockToken(c *gin.Context) string {
+	return strings.TrimSpace(c.GetHeader(lockTokenHeader))
+}
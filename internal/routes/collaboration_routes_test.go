@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"prova-fattocs/internal/app"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/mocks"
+)
+
+func TestDeleteTask_Locked(t *testing.T) {
+	collab := app.NewCollaboration(time.Minute)
+	ana := collab.Join("ana")
+	defer ana.Leave()
+	lock, err := ana.Lock(7)
+	if err != nil {
+		t.Fatalf("expected success but got error: %v", err)
+	}
+
+	deleted := false
+	mockRepo := &mocks.TaskRepositoryMock{
+		DeleteFunc: func(id, version int64) error {
+			deleted = true
+			return nil
+		},
+	}
+	r := newTestRouter(mockRepo, collab)
+
+	for _, token := range []string{"", "not-ana"} {
+		w := serve(r, http.MethodDelete, "/tasks/7", "", http.Header{lockTokenHeader: {token}})
+		if w.Code != http.StatusLocked {
+			t.Fatalf("expected 423 with token %q but got %d", token, w.Code)
+		}
+		body := decode(t, w)
+		var held domain.TaskLock
+		if err := json.Unmarshal(body.Data, &held); err != nil {
+			t.Fatalf("expected the lock in the body but got %s: %v", body.Data, err)
+		}
+		if held.TaskID != 7 || held.User != "ana" || held.ExpiresAt.IsZero() {
+			t.Errorf("expected ana's lock on task 7 but got %+v", held)
+		}
+		if strings.Contains(string(body.Data), lock.Token) {
+			t.Error("expected the lock holder's token not to be disclosed")
+		}
+	}
+	if deleted {
+		t.Fatal("expected a locked task not to be deleted")
+	}
+
+	w := serve(r, http.MethodDelete, "/tasks/7", "", http.Header{lockTokenHeader: {lock.Token}})
+	if w.Code != http.StatusOK || !deleted {
+		t.Errorf("expected the lock holder to delete the task but got %d", w.Code)
+	}
+}
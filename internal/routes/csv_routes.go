@@ -0,0 +1,147 @@
+package routes
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"prova-fattocs/internal/app"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/dto"
+	"prova-fattocs/pkg/response"
+)
+
+// maxImportBytes caps the size of a CSV upload.
+const maxImportBytes = 10 << 20
+
+func setupCSVRoutes(r *gin.Engine, taskService *app.TaskService) {
+	// Export tasks as CSV
+	// @Summary      Export tasks
+	// @Description  Streams name, cost, deadline and order as CSV. Cells a spreadsheet would run as a formula, and cells starting with a quote, are prefixed with a quote, which import removes. Accepts the filters and sort of GET /tasks; pagination is ignored.
+	// @Tags         Tasks
+	// @Produce      text/csv
+	// @Param        delimiter query string false "Field delimiter: comma, semicolon, pipe or tab, by name or percent-encoded (default comma)"
+	// @Param        decimal query string false "Decimal separator: point or comma, by name or as . and , (default point)"
+	// @Param        sort query string false "order, name, cost or deadline; prefix with - for descending"
+	// @Success      200 {string} string "CSV file"
+	// @Failure      400 {object} response.Response
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/export.csv [get]
+	r.GET("/tasks/export.csv", func(c *gin.Context) {
+		var query dto.ExportTasksQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.BadRequest(c, "Invalid query parameters", nil)
+			return
+		}
+
+		opts, err := query.ToOptions()
+		if err != nil {
+			response.Error(c, err, "Invalid query parameters")
+			return
+		}
+		format := query.ToFormat()
+		if err := format.Validate(); err != nil {
+			response.Error(c, err, "Invalid query parameters")
+			return
+		}
+
+		// Nothing reaches the client before the first row is written, so a
+		// failed query still answers with a JSON error.
+		w := &deferredWriter{c: c, contentType: "text/csv; charset=utf-8", disposition: `attachment; filename="tasks.csv"`}
+		if err := taskService.ExportCSV(w, opts, format); err != nil {
+			if w.started {
+				slog.Error("Failed to stream CSV export", "error", err)
+				return
+			}
+			response.Error(c, err, "Failed to export tasks")
+		}
+	})
+
+	// Import tasks from CSV
+	// @Summary      Import tasks
+	// @Description  Creates or updates one task per row, matching by name. Requires name, cost and deadline columns. Every row is validated first; if any row fails nothing is saved.
+	// @Tags         Tasks
+	// @Accept       text/csv
+	// @Accept       multipart/form-data
+	// @Produce      json
+	// @Param        file formData file false "CSV file, when uploading as multipart/form-data"
+	// @Param        delimiter query string false "Field delimiter: comma, semicolon, pipe or tab, by name or percent-encoded (default comma)"
+	// @Param        decimal query string false "Decimal separator: point or comma, by name or as . and , (default point)"
+	// @Param        X-Lock-Token header string false "Token of the /ws locks held on updated tasks"
+	// @Success      200 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      409 {object} response.Response
+	// @Failure      423 {object} response.Response "An updated task is locked by another session on /ws"
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/import [post]
+	r.POST("/tasks/import", func(c *gin.Context) {
+		var query dto.CSVFormatQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.BadRequest(c, "Invalid query parameters", nil)
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+		body, err := csvUpload(c)
+		if err != nil {
+			response.BadRequest(c, "Invalid upload: "+err.Error(), nil)
+			return
+		}
+		defer body.Close()
+
+		results, err := taskService.WithAudit(auditInfo(c)).WithLockToken(lockToken(c)).ImportCSV(body, query.ToFormat())
+		if err != nil {
+			response.Error(c, err, "Failed to import tasks")
+			return
+		}
+
+		report := dto.ImportReportDTO{Rows: make([]dto.ImportRowDTO, len(results))}
+		failure := 0
+		for i, result := range results {
+			row := dto.ImportRowDTO{Row: result.Row, Name: result.Name, Action: string(result.Action), Task: result.Task, Status: http.StatusOK}
+			switch result.Action {
+			case domain.ImportCreated:
+				report.Created++
+				row.Status = http.StatusCreated
+			case domain.ImportUpdated:
+				report.Updated++
+			case domain.ImportUnchanged:
+				report.Unchanged++
+			}
+			if result.Err != nil {
+				row.Status, row.Message, row.Errors = response.Describe(result.Err, "Failed to import row")
+				if !errors.Is(result.Err, domain.ErrBulkAborted) {
+					report.Failed++
+					if failure == 0 {
+						failure = row.Status
+					}
+				}
+			}
+			report.Rows[i] = row
+		}
+
+		if failure != 0 {
+			response.JSON(c, failure, "Import rolled back", report)
+			return
+		}
+		response.OK(c, "Tasks imported successfully", report)
+	})
+}
+
+// csvUpload returns the CSV sent either as the "file" field of a multipart
+// form or as the raw request body.
+func csvUpload(c *gin.Context) (io.ReadCloser, error) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		return c.Request.Body, nil
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	return header.Open()
+}
@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/pkg/response"
+)
+
+// setETag exposes the task version as a strong entity tag.
+func setETag(c *gin.Context, task domain.Task) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(task.Version, 10)))
+}
+
+// setListETag exposes the version of the task list, see
+// TaskService.ListVersion, as a strong entity tag.
+func setListETag(c *gin.Context, version string) {
+	c.Header("ETag", strconv.Quote(version))
+}
+
+// ifMatchVersion reads the version a write is conditioned on from If-Match.
+// A missing header or "*" yields 0, meaning unconditional. A tag that can
+// never match, such as a weak or malformed one, answers 412 and reports that
+// the handler must stop.
+func ifMatchVersion(c *gin.Context) (int64, bool) {
+	tag, ok := ifMatchTag(c)
+	if !ok || tag == "" {
+		return 0, ok
+	}
+
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		response.Error(c, domain.ErrPreconditionFailed, "")
+		return 0, false
+	}
+	return version, true
+}
+
+// ifMatchTag reads the opaque entity tag a write is conditioned on from
+// If-Match, like ifMatchVersion. A missing header or "*" yields "".
+func ifMatchTag(c *gin.Context) (string, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return "", true
+	}
+
+	tag, err := strconv.Unquote(header)
+	if err != nil || tag == "" || !strings.HasPrefix(header, `"`) {
+		response.Error(c, domain.ErrPreconditionFailed, "")
+		return "", false
+	}
+	return tag, true
+}
+
+// notModified reports whether a GET can be answered with 304 given the
+// representation's entity tag and modification time. If-None-Match takes
+// precedence over If-Modified-Since and compares tags weakly (RFC 9110,
+// section 13.1.2).
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if header := c.GetHeader("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := c.GetHeader("If-Modified-Since"); header != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(header)
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
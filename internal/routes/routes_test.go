@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"prova-fattocs/internal/app"
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/infra/repository"
+	"prova-fattocs/internal/mocks"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter serves the task routes from a service writing to repo and
+// honouring the edit locks held in collab, which may be nil.
+func newTestRouter(repo *mocks.TaskRepositoryMock, collab *app.Collaboration) *gin.Engine {
+	if repo.WithAuditFunc == nil {
+		repo.WithAuditFunc = func(info domain.AuditInfo) repository.TaskRepository { return repo }
+	}
+	r := gin.New()
+	SetupRoutes(r, app.NewTaskService(repo).WithCollaboration(collab))
+	return r
+}
+
+// serve sends a request to r and records the response.
+func serve(r http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// envelope is the JSON body written by the response package.
+type envelope struct {
+	StatusCode int             `json:"statusCode"`
+	Message    string          `json:"message"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) envelope {
+	t.Helper()
+	var body envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected a JSON body but got %q: %v", w.Body.String(), err)
+	}
+	return body
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deferredWriter streams a 200 response whose status and headers are only
+// sent with the first write, so a handler that fails before producing any
+// output can still answer with a JSON error.
+type deferredWriter struct {
+	c           *gin.Context
+	contentType string
+	disposition string
+	// header holds further headers, such as validators, that only describe
+	// a successful response.
+	header  http.Header
+	started bool
+}
+
+func (w *deferredWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", w.disposition)
+		for key, values := range w.header {
+			w.c.Writer.Header()[key] = values
+		}
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"prova-fattocs/internal/app"
+	"prova-fattocs/internal/domain"
+)
+
+// keepAliveInterval is how often an idle stream sends a comment so proxies
+// do not close it.
+const keepAliveInterval = 15 * time.Second
+
+func setupStreamRoutes(r *gin.Engine, taskService *app.TaskService) {
+	// Stream task changes
+	// @Summary      Stream task changes
+	// @Description  Server-Sent Events stream of committed changes: created, updated, deleted and reordered. Each event's ID is its position in the change log, the same on every server and across restarts; reconnecting with Last-Event-ID (or last_event_id) replays what was missed while it is still buffered, otherwise a reset event tells the client to reload.
+	// @Tags         Tasks
+	// @Produce      text/event-stream
+	// @Param        Last-Event-ID header string false "ID of the last event received"
+	// @Param        last_event_id query string false "Same as Last-Event-ID, for clients that cannot set headers"
+	// @Success      200 {string} string "Event stream"
+	// @Router       /tasks/stream [get]
+	r.GET("/tasks/stream", func(c *gin.Context) {
+		lastEventID := c.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("last_event_id")
+		}
+		lastID, err := strconv.ParseUint(lastEventID, 10, 64)
+		resume := lastEventID != ""
+
+		missed, events, complete, cancel := taskService.Subscribe(lastID, resume && err == nil)
+		defer cancel()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+
+		if resume && (err != nil || !complete) {
+			c.Render(-1, sse.Event{Event: "reset", Data: gin.H{"reason": "missed events are no longer available"}})
+		}
+		for _, event := range missed {
+			renderChange(c, event)
+		}
+		c.Writer.Flush()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					// Too far behind; the client reconnects and resumes.
+					return false
+				}
+				renderChange(c, event)
+				return true
+			case <-keepAlive.C:
+				_, err := io.WriteString(w, ": keep-alive\n\n")
+				return err == nil
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	})
+}
+
+func renderChange(c *gin.Context, event domain.ChangeEvent) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(event.ID, 10),
+		Event: string(event.Type),
+		Data:  event,
+	})
+}
@@ -1,37 +1,128 @@
 package routes
 
 import (
-	"database/sql"
-	"prova-fattocs/internal/domain"
+	"errors"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"prova-fattocs/internal/app"
+	"prova-fattocs/internal/domain"
 	"prova-fattocs/internal/dto"
-	"prova-fattocs/internal/infra/repository"
 	"prova-fattocs/pkg/response"
 )
 
-func SetupRoutes(r *gin.Engine, db *sql.DB) {
-	taskRepo := repository.NewPostgresTaskRepository(db)
-	taskService := app.NewTaskService(taskRepo)
+func SetupRoutes(r *gin.Engine, taskService *app.TaskService) {
+	setupCSVRoutes(r, taskService)
+	setupCalendarRoutes(r, taskService)
+	setupStreamRoutes(r, taskService)
 
-	// List all tasks
-	// @Summary      Get all tasks
-	// @Description  Returns all tasks
+	// List tasks
+	// @Summary      List tasks
+	// @Description  Returns tasks, optionally filtered, sorted and paginated. Without limit every matching task is returned.
 	// @Tags         Tasks
 	// @Produce      json
+	// @Param        limit query int false "Page size (max 500)"
+	// @Param        page query int false "Page number, starting at 1"
+	// @Param        sort query string false "order, name, cost or deadline; prefix with - for descending"
+	// @Param        cost_min query number false "Minimum cost"
+	// @Param        cost_max query number false "Maximum cost"
+	// @Param        deadline_before query string false "Only deadlines before this date (YYYY-MM-DD)"
+	// @Param        deadline_after query string false "Only deadlines after this date (YYYY-MM-DD)"
+	// @Param        name_contains query string false "Case-insensitive name substring"
+	// @Param        status query string false "Comma-separated statuses: todo, in_progress, done, cancelled"
 	// @Success      200 {object} response.Response
+	// @Header       200 {string} ETag "Version of the whole task list, for If-Match on PUT /tasks/order"
+	// @Failure      400 {object} response.Response
+	// @Failure      422 {object} response.Response
 	// @Failure      500 {object} response.Response
 	// @Router       /tasks [get]
 	r.GET("/tasks", func(c *gin.Context) {
-		tasks, err := taskService.List()
+		var query dto.ListTasksQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.BadRequest(c, "Invalid query parameters", nil)
+			return
+		}
+
+		opts, err := query.ToOptions()
+		if err != nil {
+			response.Error(c, err, "Invalid query parameters")
+			return
+		}
+
+		// Read before the tasks, so a change in between makes the tag stale
+		// rather than letting it vouch for tasks it does not cover.
+		version, err := taskService.ListVersion()
+		if err != nil {
+			response.Error(c, err, "Failed to fetch tasks")
+			return
+		}
+
+		tasks, pagination, err := taskService.List(opts)
 		if err != nil {
-			response.InternalServerError(c, "Failed to fetch tasks", nil)
+			response.Error(c, err, "Failed to fetch tasks")
 			return
 		}
 
-		response.OK(c, "Tasks retrieved successfully", tasks)
+		setListETag(c, version)
+		response.OKWithMeta(c, "Tasks retrieved successfully", tasks, pagination)
+	})
+
+	// Search tasks
+	// @Summary      Search tasks
+	// @Description  Ranked, accent-insensitive full-text search over task names. Snippets wrap matches in <mark> tags.
+	// @Tags         Tasks
+	// @Produce      json
+	// @Param        q query string true "Search terms (supports quoted phrases, or, and -word)"
+	// @Param        limit query int false "Maximum number of results (default 20, max 500)"
+	// @Success      200 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/search [get]
+	r.GET("/tasks/search", func(c *gin.Context) {
+		var query dto.SearchTasksQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.BadRequest(c, "Invalid query parameters", nil)
+			return
+		}
+
+		results, err := taskService.Search(query.Q, query.Limit)
+		if err != nil {
+			response.Error(c, err, "Failed to search tasks")
+			return
+		}
+
+		response.OK(c, "Tasks retrieved successfully", results)
+	})
+
+	// Get a task
+	// @Summary      Get task
+	// @Description  Returns a single task by ID
+	// @Tags         Tasks
+	// @Produce      json
+	// @Param        id path int true "Task ID"
+	// @Success      200 {object} response.Response
+	// @Header       200 {string} ETag "Current task version"
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/{id} [get]
+	r.GET("/tasks/:id", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid ID", nil)
+			return
+		}
+
+		task, err := taskService.GetByID(id)
+		if err != nil {
+			response.Error(c, err, "Failed to fetch task")
+			return
+		}
+
+		setETag(c, task)
+		response.OK(c, "Task retrieved successfully", task)
 	})
 
 	// Create a task
@@ -43,45 +134,49 @@ func SetupRoutes(r *gin.Engine, db *sql.DB) {
 	// @Param        task body dto.CreateTaskDTO true "Task payload"
 	// @Success      201 {object} response.Response
 	// @Failure      400 {object} response.Response
+	// @Failure      409 {object} response.Response
+	// @Failure      422 {object} response.Response
 	// @Failure      500 {object} response.Response
 	// @Router       /tasks [post]
 	r.POST("/tasks", func(c *gin.Context) {
 		var input dto.CreateTaskDTO
-		if err := c.ShouldBindJSON(&input); err != nil {
-			response.BadRequest(c, "Invalid input", nil)
+		if !bindJSON(c, &input) {
 			return
 		}
 
-		task := domain.Task{
-			Name:     input.Name,
-			Cost:     input.Cost,
-			Deadline: input.Deadline,
+		task, err := input.ToTask()
+		if err != nil {
+			response.Error(c, err, "Invalid input")
+			return
 		}
 
-		err := taskService.Create(task)
+		created, err := taskService.WithAudit(auditInfo(c)).Create(task)
 		if err != nil {
-			if err.Error() == "task with this name already exists" {
-				response.BadRequest(c, err.Error(), nil)
-				return
-			}
-			response.InternalServerError(c, "Failed to create task", nil)
+			response.Error(c, err, "Failed to create task")
 			return
 		}
 
-		response.Created(c, "Task created successfully", task)
+		response.Created(c, "Task created successfully", created)
 	})
 
 	// Update a task
 	// @Summary      Update task
-	// @Description  Updates an existing task
+	// @Description  Updates an existing task. Fails with 423 while another session holds its lock on /ws; the holder sends the lock's token in X-Lock-Token.
 	// @Tags         Tasks
 	// @Accept       json
 	// @Produce      json
 	// @Param        id path int true "Task ID"
 	// @Param        task body dto.UpdateTaskDTO true "Updated task data"
+	// @Param        If-Match header string false "ETag of the version being modified"
+	// @Param        X-Lock-Token header string false "Token of the /ws lock held on the task"
 	// @Success      200 {object} response.Response
+	// @Header       200 {string} ETag "New task version"
 	// @Failure      400 {object} response.Response
 	// @Failure      404 {object} response.Response
+	// @Failure      409 {object} response.Response
+	// @Failure      412 {object} response.Response
+	// @Failure      423 {object} response.Response "Locked by another session on /ws"
+	// @Failure      422 {object} response.Response
 	// @Failure      500 {object} response.Response
 	// @Router       /tasks/{id} [put]
 	r.PUT("/tasks/:id", func(c *gin.Context) {
@@ -91,39 +186,153 @@ func SetupRoutes(r *gin.Engine, db *sql.DB) {
 			return
 		}
 
+		version, ok := ifMatchVersion(c)
+		if !ok {
+			return
+		}
+
 		var input dto.UpdateTaskDTO
-		if err := c.ShouldBindJSON(&input); err != nil {
-			response.BadRequest(c, "Invalid input", nil)
+		if !bindJSON(c, &input) {
 			return
 		}
 
-		task := domain.Task{
-			Name:     input.Name,
-			Cost:     input.Cost,
-			Deadline: input.Deadline,
+		task, err := input.ToTask()
+		if err != nil {
+			response.Error(c, err, "Invalid input")
+			return
 		}
+		task.Version = version
 
-		err = taskService.Update(id, task)
+		updated, err := taskService.WithAudit(auditInfo(c)).WithLockToken(lockToken(c)).Update(id, task)
 		if err != nil {
-			if err.Error() == "task with this name already exists" {
-				response.BadRequest(c, err.Error(), nil)
-				return
-			}
-			response.InternalServerError(c, "Failed to update task", nil)
+			response.Error(c, err, "Failed to update task")
 			return
 		}
 
-		response.OK(c, "Task updated successfully", task)
+		setETag(c, updated)
+		response.OK(c, "Task updated successfully", updated)
+	})
+
+	// Partially update a task
+	// @Summary      Patch task
+	// @Description  Updates only the fields present in the payload. Fails with 423 while another session holds its lock on /ws; the holder sends the lock's token in X-Lock-Token.
+	// @Tags         Tasks
+	// @Accept       json
+	// @Produce      json
+	// @Param        id path int true "Task ID"
+	// @Param        task body dto.PatchTaskDTO true "Fields to change"
+	// @Param        If-Match header string false "ETag of the version being modified"
+	// @Param        X-Lock-Token header string false "Token of the /ws lock held on the task"
+	// @Success      200 {object} response.Response
+	// @Header       200 {string} ETag "New task version"
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      409 {object} response.Response
+	// @Failure      412 {object} response.Response
+	// @Failure      423 {object} response.Response "Locked by another session on /ws"
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/{id} [patch]
+	r.PATCH("/tasks/:id", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid ID", nil)
+			return
+		}
+
+		version, ok := ifMatchVersion(c)
+		if !ok {
+			return
+		}
+
+		var input dto.PatchTaskDTO
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		patch, err := input.ToPatch()
+		if err != nil {
+			response.Error(c, err, "Invalid input")
+			return
+		}
+		patch.Version = version
+
+		patched, err := taskService.WithAudit(auditInfo(c)).WithLockToken(lockToken(c)).Patch(id, patch)
+		if err != nil {
+			response.Error(c, err, "Failed to update task")
+			return
+		}
+
+		setETag(c, patched)
+		response.OK(c, "Task updated successfully", patched)
+	})
+
+	// Change a task status
+	// @Summary      Transition task
+	// @Description  Moves a task to another workflow status (todo, in_progress, done, cancelled) if allowed from its current one. Fails with 423 while another session holds its lock on /ws; the holder sends the lock's token in X-Lock-Token.
+	// @Tags         Tasks
+	// @Accept       json
+	// @Produce      json
+	// @Param        id path int true "Task ID"
+	// @Param        body body dto.TransitionTaskDTO true "Target status"
+	// @Param        If-Match header string false "ETag of the version being modified"
+	// @Param        X-Lock-Token header string false "Token of the /ws lock held on the task"
+	// @Success      200 {object} response.Response
+	// @Header       200 {string} ETag "New task version"
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      409 {object} response.Response
+	// @Failure      412 {object} response.Response
+	// @Failure      423 {object} response.Response "Locked by another session on /ws"
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/{id}/transitions [post]
+	r.POST("/tasks/:id/transitions", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid ID", nil)
+			return
+		}
+
+		version, ok := ifMatchVersion(c)
+		if !ok {
+			return
+		}
+
+		var input dto.TransitionTaskDTO
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		status, err := input.ToStatus()
+		if err != nil {
+			response.Error(c, err, "Invalid input")
+			return
+		}
+
+		updated, err := taskService.WithAudit(auditInfo(c)).WithLockToken(lockToken(c)).Transition(id, status, version)
+		if err != nil {
+			response.Error(c, err, "Failed to change task status")
+			return
+		}
+
+		setETag(c, updated)
+		response.OK(c, "Task status changed successfully", updated)
 	})
 
 	// Delete a task
 	// @Summary      Delete task
-	// @Description  Deletes an existing task
+	// @Description  Moves an existing task to the trash, where it can be restored until it is purged. Fails with 423 while another session holds its lock on /ws; the holder sends the lock's token in X-Lock-Token.
 	// @Tags         Tasks
 	// @Produce      json
 	// @Param        id path int true "Task ID"
+	// @Param        If-Match header string false "ETag of the version being modified"
+	// @Param        X-Lock-Token header string false "Token of the /ws lock held on the task"
 	// @Success      200 {object} response.Response
 	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      412 {object} response.Response
+	// @Failure      423 {object} response.Response "Locked by another session on /ws"
 	// @Failure      500 {object} response.Response
 	// @Router       /tasks/{id} [delete]
 	r.DELETE("/tasks/:id", func(c *gin.Context) {
@@ -133,24 +342,226 @@ func SetupRoutes(r *gin.Engine, db *sql.DB) {
 			return
 		}
 
-		if err := taskService.Delete(int64(id)); err != nil {
-			response.InternalServerError(c, "Failed to delete task", nil)
+		version, ok := ifMatchVersion(c)
+		if !ok {
+			return
+		}
+
+		if err := taskService.WithAudit(auditInfo(c)).WithLockToken(lockToken(c)).Delete(int64(id), version); err != nil {
+			response.Error(c, err, "Failed to delete task")
 			return
 		}
 
 		response.OK(c, "Task deleted successfully", nil)
 	})
 
+	// List deleted tasks
+	// @Summary      List trash
+	// @Description  Returns the tasks in the trash, most recently deleted first
+	// @Tags         Tasks
+	// @Produce      json
+	// @Success      200 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/trash [get]
+	r.GET("/tasks/trash", func(c *gin.Context) {
+		tasks, err := taskService.ListDeleted()
+		if err != nil {
+			response.Error(c, err, "Failed to fetch deleted tasks")
+			return
+		}
+
+		response.OK(c, "Deleted tasks retrieved successfully", tasks)
+	})
+
+	// Restore a task
+	// @Summary      Restore task
+	// @Description  Takes a task out of the trash and appends it to the end of the list. Fails with 423 while another session holds its lock on /ws; the holder sends the lock's token in X-Lock-Token.
+	// @Tags         Tasks
+	// @Produce      json
+	// @Param        id path int true "Task ID"
+	// @Param        If-Match header string false "ETag of the version being modified"
+	// @Param        X-Lock-Token header string false "Token of the /ws lock held on the task"
+	// @Success      200 {object} response.Response
+	// @Header       200 {string} ETag "Current task version"
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      409 {object} response.Response
+	// @Failure      412 {object} response.Response
+	// @Failure      423 {object} response.Response "Locked by another session on /ws"
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/{id}/restore [post]
+	r.POST("/tasks/:id/restore", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid ID", nil)
+			return
+		}
+
+		version, ok := ifMatchVersion(c)
+		if !ok {
+			return
+		}
+
+		task, err := taskService.WithAudit(auditInfo(c)).WithLockToken(lockToken(c)).Restore(id, version)
+		if err != nil {
+			response.Error(c, err, "Failed to restore task")
+			return
+		}
+
+		setETag(c, task)
+		response.OK(c, "Task restored successfully", task)
+	})
+
+	// List task revisions
+	// @Summary      List task revisions
+	// @Description  Returns the saved states of a task, newest first, including while it is in the trash
+	// @Tags         Tasks
+	// @Produce      json
+	// @Param        id path int true "Task ID"
+	// @Success      200 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/{id}/revisions [get]
+	r.GET("/tasks/:id/revisions", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid ID", nil)
+			return
+		}
+
+		revisions, err := taskService.ListRevisions(id)
+		if err != nil {
+			response.Error(c, err, "Failed to fetch task revisions")
+			return
+		}
+
+		response.OK(c, "Task revisions retrieved successfully", revisions)
+	})
+
+	// Revert a task
+	// @Summary      Revert task
+	// @Description  Restores the name, cost and deadline saved in a revision, taking the task out of the trash if needed. Fails with 423 while another session holds its lock on /ws; the holder sends the lock's token in X-Lock-Token.
+	// @Tags         Tasks
+	// @Produce      json
+	// @Param        id path int true "Task ID"
+	// @Param        revision query int true "Revision to restore"
+	// @Param        If-Match header string false "ETag of the version being modified"
+	// @Param        X-Lock-Token header string false "Token of the /ws lock held on the task"
+	// @Success      200 {object} response.Response
+	// @Header       200 {string} ETag "Current task version"
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      409 {object} response.Response
+	// @Failure      412 {object} response.Response
+	// @Failure      423 {object} response.Response "Locked by another session on /ws"
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/{id}/revert [post]
+	r.POST("/tasks/:id/revert", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid ID", nil)
+			return
+		}
+
+		version, ok := ifMatchVersion(c)
+		if !ok {
+			return
+		}
+
+		var query dto.RevertTaskQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.BadRequest(c, "Invalid query parameters", nil)
+			return
+		}
+
+		task, err := taskService.WithAudit(auditInfo(c)).WithLockToken(lockToken(c)).Revert(id, query.Revision, version)
+		if err != nil {
+			response.Error(c, err, "Failed to revert task")
+			return
+		}
+
+		setETag(c, task)
+		response.OK(c, "Task reverted successfully", task)
+	})
+
+	// Bulk create, update and delete
+	// @Summary      Bulk change tasks
+	// @Description  Applies a list of create, update and delete operations in order. In atomic mode (the default) they share one transaction and any failure rolls all of them back; in best_effort mode each is applied on its own. Each item reports the status it would have had as a single request.
+	// @Tags         Tasks
+	// @Accept       json
+	// @Produce      json
+	// @Param        body body dto.BulkTasksDTO true "Operations to apply"
+	// @Param        X-Lock-Token header string false "Token of the /ws locks held on updated or deleted tasks"
+	// @Success      200 {object} response.Response
+	// @Success      207 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      409 {object} response.Response
+	// @Failure      423 {object} response.Response "An updated or deleted task is locked by another session on /ws"
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/bulk [post]
+	r.POST("/tasks/bulk", func(c *gin.Context) {
+		var input dto.BulkTasksDTO
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		atomic, err := input.Atomic()
+		if err != nil {
+			response.Error(c, err, "Invalid bulk request")
+			return
+		}
+
+		results, err := taskService.WithAudit(auditInfo(c)).WithLockToken(lockToken(c)).Bulk(input.ToOperations(), atomic)
+		if err != nil {
+			response.Error(c, err, "Failed to apply bulk operations")
+			return
+		}
+
+		items := make([]dto.BulkResultDTO, len(results))
+		failure := 0
+		for i, result := range results {
+			item := dto.BulkResultDTO{Index: i, Action: string(result.Action), ID: result.ID, Task: result.Task, Status: http.StatusOK}
+			if result.Action == domain.BulkCreate {
+				item.Status = http.StatusCreated
+			}
+			if result.Err != nil {
+				item.Status, item.Message, item.Errors = response.Describe(result.Err, "Failed to apply operation")
+				if failure == 0 && !errors.Is(result.Err, domain.ErrBulkAborted) {
+					failure = item.Status
+				}
+			}
+			items[i] = item
+		}
+
+		switch {
+		case failure == 0:
+			response.OK(c, "Bulk operations applied successfully", items)
+		case atomic:
+			response.JSON(c, failure, "Bulk operations rolled back", items)
+		default:
+			response.MultiStatus(c, "Some bulk operations failed", items)
+		}
+	})
+
 	// Reorder a task
 	// @Summary      Reorder task
-	// @Description  Changes the order of a task
+	// @Description  Moves a task to the given position, shifting the tasks in between. Fails with 423 while another session holds its lock on /ws; the holder sends the lock's token in X-Lock-Token.
 	// @Tags         Tasks
 	// @Accept       json
 	// @Produce      json
 	// @Param        id path int true "Task ID"
-	// @Param        body body dto.ReorderTaskDTO true "New order"
+	// @Param        body body dto.ReorderTaskDTO true "New position"
+	// @Param        If-Match header string false "ETag of the version being modified"
+	// @Param        X-Lock-Token header string false "Token of the /ws lock held on the task"
 	// @Success      200 {object} response.Response
 	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      412 {object} response.Response
+	// @Failure      423 {object} response.Response "Locked by another session on /ws"
+	// @Failure      422 {object} response.Response
 	// @Failure      500 {object} response.Response
 	// @Router       /tasks/{id}/reorder [post]
 	r.POST("/tasks/:id/reorder", func(c *gin.Context) {
@@ -160,17 +571,57 @@ func SetupRoutes(r *gin.Engine, db *sql.DB) {
 			return
 		}
 
+		version, ok := ifMatchVersion(c)
+		if !ok {
+			return
+		}
+
 		var input dto.ReorderTaskDTO
-		if err := c.ShouldBindJSON(&input); err != nil {
-			response.BadRequest(c, "Invalid input", nil)
+		if !bindJSON(c, &input) {
 			return
 		}
 
-		if err := taskService.Reorder(int64(id), input.Order); err != nil {
-			response.InternalServerError(c, "Failed to reorder task", nil)
+		if err := taskService.WithAudit(auditInfo(c)).WithLockToken(lockToken(c)).Reorder(int64(id), input.Order, version); err != nil {
+			response.Error(c, err, "Failed to reorder task")
 			return
 		}
 
 		response.OK(c, "Task reordered successfully", nil)
 	})
+
+	// Reorder all tasks
+	// @Summary      Reorder all tasks
+	// @Description  Sets the order of every task at once from the full list of task IDs. With If-Match, fails with 412 if any task was created, deleted, edited or moved since the list was read.
+	// @Tags         Tasks
+	// @Accept       json
+	// @Produce      json
+	// @Param        body body dto.ReorderTasksDTO true "Task IDs in the desired order"
+	// @Param        If-Match header string false "ETag of the task list returned by GET /tasks"
+	// @Success      200 {object} response.Response
+	// @Header       200 {string} ETag "New version of the task list"
+	// @Failure      400 {object} response.Response
+	// @Failure      412 {object} response.Response
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /tasks/order [put]
+	r.PUT("/tasks/order", func(c *gin.Context) {
+		version, ok := ifMatchTag(c)
+		if !ok {
+			return
+		}
+
+		var input dto.ReorderTasksDTO
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		tasks, version, err := taskService.WithAudit(auditInfo(c)).ReorderAll(input.IDs, version)
+		if err != nil {
+			response.Error(c, err, "Failed to reorder tasks")
+			return
+		}
+
+		setListETag(c, version)
+		response.OK(c, "Tasks reordered successfully", tasks)
+	})
 }
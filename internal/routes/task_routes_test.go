@@ -0,0 +1,134 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"prova-fattocs/internal/domain"
+	"prova-fattocs/internal/mocks"
+)
+
+const updateBody = `{"name":"Comprar materiais","cost":"1250.50","deadline":"2025-08-10"}`
+
+func TestUpdateTask_IfMatch(t *testing.T) {
+	var versions []int64
+	mockRepo := &mocks.TaskRepositoryMock{
+		ExistsByNameFunc: func(name string, id int64) (bool, error) { return false, nil },
+		UpdateFunc: func(id int64, task domain.Task) (domain.Task, error) {
+			versions = append(versions, task.Version)
+			if task.Version != 0 && task.Version != 3 {
+				return domain.Task{}, domain.ErrPreconditionFailed
+			}
+			task.ID, task.Version = id, 4
+			return task, nil
+		},
+	}
+	r := newTestRouter(mockRepo, nil)
+
+	cases := []struct {
+		ifMatch string
+		status  int
+		etag    string
+	}{
+		{"", http.StatusOK, `"4"`},
+		{"*", http.StatusOK, `"4"`},
+		{`"3"`, http.StatusOK, `"4"`},
+		{`"2"`, http.StatusPreconditionFailed, ""},
+		{`W/"3"`, http.StatusPreconditionFailed, ""},
+		{`3`, http.StatusPreconditionFailed, ""},
+		{`"abc"`, http.StatusPreconditionFailed, ""},
+	}
+	for _, tc := range cases {
+		var header http.Header
+		if tc.ifMatch != "" {
+			header = http.Header{"If-Match": {tc.ifMatch}}
+		}
+		w := serve(r, http.MethodPut, "/tasks/7", updateBody, header)
+		if w.Code != tc.status {
+			t.Errorf("If-Match %s: expected %d but got %d: %s", tc.ifMatch, tc.status, w.Code, w.Body.String())
+			continue
+		}
+		if got := w.Header().Get("ETag"); got != tc.etag {
+			t.Errorf("If-Match %s: expected ETag %q but got %q", tc.ifMatch, tc.etag, got)
+		}
+		if tc.status == http.StatusPreconditionFailed {
+			if body := decode(t, w); body.Message != domain.ErrPreconditionFailed.Error() {
+				t.Errorf("If-Match %s: expected %q but got %q", tc.ifMatch, domain.ErrPreconditionFailed, body.Message)
+			}
+		}
+	}
+
+	// Tags that can never match are refused before the task is written.
+	want := []int64{0, 0, 3, 2}
+	if len(versions) != len(want) {
+		t.Fatalf("expected the writes to be conditioned on %v but got %v", want, versions)
+	}
+	for i := range want {
+		if versions[i] != want[i] {
+			t.Errorf("expected the writes to be conditioned on %v but got %v", want, versions)
+			break
+		}
+	}
+}
+
+func TestReorderAllTasks_IfMatch(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		ReorderAllFunc: func(ids []int64, version string) ([]domain.Task, string, error) {
+			if version != "" && version != "list-v1" {
+				return nil, "", domain.ErrPreconditionFailed
+			}
+			return []domain.Task{{ID: ids[0], OrderNumber: 1}, {ID: ids[1], OrderNumber: 2}}, "list-v2", nil
+		},
+	}
+	r := newTestRouter(mockRepo, nil)
+
+	w := serve(r, http.MethodPut, "/tasks/order", `{"ids":[2,1]}`, http.Header{"If-Match": {`"list-v0"`}})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for a stale list tag but got %d", w.Code)
+	}
+	if got := w.Header().Get("ETag"); got != "" {
+		t.Errorf("expected no ETag on a refused reorder but got %q", got)
+	}
+
+	w = serve(r, http.MethodPut, "/tasks/order", `{"ids":[2,1]}`, http.Header{"If-Match": {`"list-v1"`}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 but got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("ETag"); got != `"list-v2"` {
+		t.Errorf("expected the new list tag but got %q", got)
+	}
+}
+
+func TestCreateTask_FieldErrors(t *testing.T) {
+	mockRepo := &mocks.TaskRepositoryMock{
+		CreateFunc: func(task domain.Task) (domain.Task, error) {
+			t.Fatal("expected an invalid task not to be created")
+			return task, nil
+		},
+	}
+	r := newTestRouter(mockRepo, nil)
+
+	w := serve(r, http.MethodPost, "/tasks", `{"name":"  ","cost":"12.345","deadline":"2025-02-30"}`, nil)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 but got %d: %s", w.Code, w.Body.String())
+	}
+	var fields []domain.FieldError
+	if err := json.Unmarshal(decode(t, w).Data, &fields); err != nil {
+		t.Fatalf("expected the field errors in the body: %v", err)
+	}
+	got := make(map[string]string, len(fields))
+	for _, field := range fields {
+		got[field.Field] = field.Code
+	}
+	for _, name := range []string{"name", "cost", "deadline"} {
+		if got[name] == "" {
+			t.Errorf("expected an error for %s but got %+v", name, fields)
+		}
+	}
+
+	w = serve(r, http.MethodPost, "/tasks", `{"name":`, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for malformed JSON but got %d", w.Code)
+	}
+}
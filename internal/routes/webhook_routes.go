@@ -0,0 +1,253 @@
+package routes
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"prova-fattocs/internal/app"
+	"prova-fattocs/internal/dto"
+	"prova-fattocs/pkg/response"
+)
+
+func SetupWebhookRoutes(r *gin.Engine, webhookService *app.WebhookService) {
+	// Create a webhook
+	// @Summary      Create webhook
+	// @Description  Subscribes a URL to task events. Deliveries are POSTed as JSON signed with X-Webhook-Signature: sha256=HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body). Without a secret one is generated; it is only returned here. Loopback, private and link-local targets are refused unless listed in WEBHOOK_ALLOWED_NETWORKS.
+	// @Tags         Webhooks
+	// @Accept       json
+	// @Produce      json
+	// @Param        webhook body dto.WebhookDTO true "Subscription"
+	// @Success      201 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /webhooks [post]
+	r.POST("/webhooks", func(c *gin.Context) {
+		var input dto.WebhookDTO
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		webhook, err := webhookService.Create(input.ToWebhook())
+		if err != nil {
+			response.Error(c, err, "Failed to create webhook")
+			return
+		}
+
+		response.Created(c, "Webhook created successfully", webhook)
+	})
+
+	// List webhooks
+	// @Summary      List webhooks
+	// @Description  Returns every subscription, without secrets
+	// @Tags         Webhooks
+	// @Produce      json
+	// @Success      200 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /webhooks [get]
+	r.GET("/webhooks", func(c *gin.Context) {
+		webhooks, err := webhookService.List()
+		if err != nil {
+			response.Error(c, err, "Failed to fetch webhooks")
+			return
+		}
+
+		response.OK(c, "Webhooks retrieved successfully", webhooks)
+	})
+
+	// Get a webhook
+	// @Summary      Get webhook
+	// @Description  Returns a subscription, without its secret
+	// @Tags         Webhooks
+	// @Produce      json
+	// @Param        id path int true "Webhook ID"
+	// @Success      200 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /webhooks/{id} [get]
+	r.GET("/webhooks/:id", func(c *gin.Context) {
+		id, ok := webhookID(c)
+		if !ok {
+			return
+		}
+
+		webhook, err := webhookService.GetByID(id)
+		if err != nil {
+			response.Error(c, err, "Failed to fetch webhook")
+			return
+		}
+
+		response.OK(c, "Webhook retrieved successfully", webhook)
+	})
+
+	// Update a webhook
+	// @Summary      Update webhook
+	// @Description  Replaces a subscription. Without a secret the current one is kept.
+	// @Tags         Webhooks
+	// @Accept       json
+	// @Produce      json
+	// @Param        id path int true "Webhook ID"
+	// @Param        webhook body dto.WebhookDTO true "Subscription"
+	// @Success      200 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /webhooks/{id} [put]
+	r.PUT("/webhooks/:id", func(c *gin.Context) {
+		id, ok := webhookID(c)
+		if !ok {
+			return
+		}
+
+		var input dto.WebhookDTO
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		webhook, err := webhookService.Update(id, input.ToWebhook())
+		if err != nil {
+			response.Error(c, err, "Failed to update webhook")
+			return
+		}
+
+		response.OK(c, "Webhook updated successfully", webhook)
+	})
+
+	// Delete a webhook
+	// @Summary      Delete webhook
+	// @Description  Removes a subscription together with its deliveries
+	// @Tags         Webhooks
+	// @Produce      json
+	// @Param        id path int true "Webhook ID"
+	// @Success      200 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /webhooks/{id} [delete]
+	r.DELETE("/webhooks/:id", func(c *gin.Context) {
+		id, ok := webhookID(c)
+		if !ok {
+			return
+		}
+
+		if err := webhookService.Delete(id); err != nil {
+			response.Error(c, err, "Failed to delete webhook")
+			return
+		}
+
+		response.OK(c, "Webhook deleted successfully", nil)
+	})
+
+	// List deliveries
+	// @Summary      List webhook deliveries
+	// @Description  Returns the deliveries made to a subscription, newest first
+	// @Tags         Webhooks
+	// @Produce      json
+	// @Param        id path int true "Webhook ID"
+	// @Param        limit query int false "Page size (default 100, max 500)"
+	// @Param        page query int false "Page number, starting at 1"
+	// @Success      200 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      422 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /webhooks/{id}/deliveries [get]
+	r.GET("/webhooks/:id/deliveries", func(c *gin.Context) {
+		id, ok := webhookID(c)
+		if !ok {
+			return
+		}
+
+		var query dto.DeliveriesQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.BadRequest(c, "Invalid query parameters", nil)
+			return
+		}
+
+		deliveries, pagination, err := webhookService.Deliveries(id, query.ToListOptions())
+		if err != nil {
+			response.Error(c, err, "Failed to fetch webhook deliveries")
+			return
+		}
+
+		response.OKWithMeta(c, "Webhook deliveries retrieved successfully", deliveries, pagination)
+	})
+
+	// Get a delivery
+	// @Summary      Get webhook delivery
+	// @Description  Returns a delivery with its payload and the log of every attempt
+	// @Tags         Webhooks
+	// @Produce      json
+	// @Param        id path int true "Webhook ID"
+	// @Param        deliveryID path int true "Delivery ID"
+	// @Success      200 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /webhooks/{id}/deliveries/{deliveryID} [get]
+	r.GET("/webhooks/:id/deliveries/:deliveryID", func(c *gin.Context) {
+		id, deliveryID, ok := webhookDeliveryID(c)
+		if !ok {
+			return
+		}
+
+		delivery, err := webhookService.Delivery(id, deliveryID)
+		if err != nil {
+			response.Error(c, err, "Failed to fetch webhook delivery")
+			return
+		}
+
+		response.OK(c, "Webhook delivery retrieved successfully", delivery)
+	})
+
+	// Redeliver
+	// @Summary      Redeliver webhook
+	// @Description  Queues the event of a delivery to be sent again right away, as a new delivery with the same event ID
+	// @Tags         Webhooks
+	// @Produce      json
+	// @Param        id path int true "Webhook ID"
+	// @Param        deliveryID path int true "Delivery ID"
+	// @Success      201 {object} response.Response
+	// @Failure      400 {object} response.Response
+	// @Failure      404 {object} response.Response
+	// @Failure      500 {object} response.Response
+	// @Router       /webhooks/{id}/deliveries/{deliveryID}/redeliver [post]
+	r.POST("/webhooks/:id/deliveries/:deliveryID/redeliver", func(c *gin.Context) {
+		id, deliveryID, ok := webhookDeliveryID(c)
+		if !ok {
+			return
+		}
+
+		delivery, err := webhookService.Redeliver(id, deliveryID)
+		if err != nil {
+			response.Error(c, err, "Failed to redeliver webhook")
+			return
+		}
+
+		response.Created(c, "Webhook redelivery queued successfully", delivery)
+	})
+}
+
+func webhookID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid ID", nil)
+		return 0, false
+	}
+	return id, true
+}
+
+func webhookDeliveryID(c *gin.Context) (int64, int64, bool) {
+	id, ok := webhookID(c)
+	if !ok {
+		return 0, 0, false
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("deliveryID"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid delivery ID", nil)
+		return 0, 0, false
+	}
+	return id, deliveryID, true
+}
@@ -1,9 +1,153 @@
-﻿DROP TABLE IF EXISTS public.tasks;
+﻿DROP TABLE IF EXISTS public.webhook_delivery_attempts;
+DROP TABLE IF EXISTS public.webhook_deliveries;
+DROP TABLE IF EXISTS public.webhooks;
+DROP TABLE IF EXISTS public.outbox;
+DROP TABLE IF EXISTS public.task_events;
+DROP FUNCTION IF EXISTS public.task_events_append_only();
+DROP TABLE IF EXISTS public.task_revisions;
+DROP TABLE IF EXISTS public.tasks;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS public.portuguese_unaccent;
+
+-- Portuguese stemming that also ignores accents, so "acao" matches "ação".
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE TEXT SEARCH CONFIGURATION public.portuguese_unaccent (COPY = pg_catalog.portuguese);
+ALTER TEXT SEARCH CONFIGURATION public.portuguese_unaccent
+    ALTER MAPPING FOR hword, hword_part, word WITH unaccent, portuguese_stem;
+
 CREATE TABLE public.tasks
 (
     id                 SERIAL PRIMARY KEY,
-    name               VARCHAR(255) UNIQUE NOT NULL,
+    name               VARCHAR(255)        NOT NULL,
     cost               NUMERIC(10, 2)      NOT NULL,
     deadline           DATE                NOT NULL,
-    presentation_order INTEGER UNIQUE      NOT NULL
+    -- NULL while the task is in the trash.
+    presentation_order INTEGER,
+    status             VARCHAR(20)         NOT NULL DEFAULT 'todo'
+        CHECK (status IN ('todo', 'in_progress', 'done', 'cancelled')),
+    completed_at       TIMESTAMPTZ,
+    deleted_at         TIMESTAMPTZ,
+    version            INTEGER             NOT NULL DEFAULT 1,
+    -- Extend with further text columns (e.g. a description) as they are added.
+    search_vector      TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('public.portuguese_unaccent', name)
+    ) STORED,
+    CONSTRAINT tasks_presentation_order_key UNIQUE (presentation_order) DEFERRABLE INITIALLY IMMEDIATE
+);
+
+-- Names only need to be unique among tasks that are not in the trash.
+CREATE UNIQUE INDEX tasks_name_key ON public.tasks (name) WHERE deleted_at IS NULL;
+CREATE INDEX tasks_deleted_at_idx ON public.tasks (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX tasks_search_vector_idx ON public.tasks USING GIN (search_vector);
+
+-- Snapshot of the editable fields of each saved state of a task, numbered
+-- from 1 per task, so edits can be reverted.
+CREATE TABLE public.task_revisions
+(
+    task_id    INTEGER        NOT NULL REFERENCES public.tasks (id) ON DELETE CASCADE,
+    revision   INTEGER        NOT NULL,
+    name       VARCHAR(255)   NOT NULL,
+    cost       NUMERIC(10, 2) NOT NULL,
+    deadline   DATE           NOT NULL,
+    created_at TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    PRIMARY KEY (task_id, revision)
+);
+
+-- Audit log of every task mutation, written in the same transaction as the
+-- change itself. There is no foreign key to tasks: the history of a task
+-- outlives its purge.
+CREATE TABLE public.task_events
+(
+    id         BIGSERIAL PRIMARY KEY,
+    task_id    INTEGER      NOT NULL,
+    action     VARCHAR(20)  NOT NULL,
+    old_data   JSONB,
+    new_data   JSONB,
+    actor      VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX task_events_task_id_idx ON public.task_events (task_id, id);
+CREATE INDEX task_events_created_at_idx ON public.task_events (created_at);
+
+-- The log is append-only.
+CREATE FUNCTION public.task_events_append_only() RETURNS trigger
+    LANGUAGE plpgsql AS
+$$
+BEGIN
+    RAISE EXCEPTION 'task_events is append-only';
+END;
+$$;
+
+CREATE TRIGGER task_events_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON public.task_events
+    FOR EACH STATEMENT EXECUTE FUNCTION public.task_events_append_only();
+
+-- Changes to publish to live clients and webhooks, written in the same
+-- transaction as the change itself. Writers hold an advisory lock from
+-- writing their rows until they commit, so ids follow commit order: every
+-- server follows the table by id for its live clients, and one at a time
+-- queues webhooks and sets published_at. Published rows are purged after a
+-- retention period. Consumers discard events whose event_id they have
+-- already seen.
+CREATE TABLE public.outbox
+(
+    id           BIGSERIAL PRIMARY KEY,
+    event_id     UUID        NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    task_id      INTEGER,
+    type         VARCHAR(20) NOT NULL,
+    payload      JSONB       NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_unpublished_idx ON public.outbox (id) WHERE published_at IS NULL;
+CREATE INDEX outbox_published_at_idx ON public.outbox (published_at);
+
+-- Subscriptions to task events. An empty events array receives every event.
+CREATE TABLE public.webhooks
+(
+    id         SERIAL PRIMARY KEY,
+    url        TEXT         NOT NULL,
+    events     TEXT[]       NOT NULL DEFAULT '{}',
+    secret     VARCHAR(255) NOT NULL,
+    active     BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+-- Queue of events to send to each subscription. Pending deliveries are sent
+-- once next_attempt_at has passed; it is NULL when no attempt is left.
+CREATE TABLE public.webhook_deliveries
+(
+    id              BIGSERIAL PRIMARY KEY,
+    webhook_id      INTEGER     NOT NULL REFERENCES public.webhooks (id) ON DELETE CASCADE,
+    event_id        VARCHAR(64) NOT NULL,
+    event           VARCHAR(50) NOT NULL,
+    payload         JSONB       NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ          DEFAULT now(),
+    -- Manual redeliveries repeat the event_id of the delivery they copy.
+    redelivery      BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- An event is queued once per subscription however often it is published.
+CREATE UNIQUE INDEX webhook_deliveries_event_key ON public.webhook_deliveries (webhook_id, event_id) WHERE NOT redelivery;
+
+CREATE INDEX webhook_deliveries_due_idx ON public.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_id_idx ON public.webhook_deliveries (webhook_id, id);
+
+-- Log of every attempt at sending a delivery.
+CREATE TABLE public.webhook_delivery_attempts
+(
+    delivery_id  BIGINT      NOT NULL REFERENCES public.webhook_deliveries (id) ON DELETE CASCADE,
+    attempt      INTEGER     NOT NULL,
+    status_code  INTEGER     NOT NULL DEFAULT 0,
+    error        TEXT        NOT NULL DEFAULT '',
+    duration_ms  BIGINT      NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (delivery_id, attempt)
 );
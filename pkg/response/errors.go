@@ -0,0 +1,52 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"prova-fattocs/internal/domain"
+)
+
+// Error writes the response matching a domain error. Errors that are not
+// part of the domain are reported as 500 with the given fallback message so
+// internal details never leak to clients.
+func Error(c *gin.Context, err error, fallback string) {
+	status, message, data := Describe(err, fallback)
+	JSON(c, status, message, data)
+}
+
+// Describe returns the status code, message and data Error would write for
+// err, for responses that report several errors at once.
+func Describe(err error, fallback string) (int, string, interface{}) {
+	var validationErr *domain.ValidationError
+	var lockedErr *domain.LockedError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity, domain.ErrValidation.Error(), validationErr.Fields
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusUnprocessableEntity, err.Error(), nil
+	case errors.Is(err, domain.ErrRevisionNotFound):
+		return http.StatusNotFound, domain.ErrRevisionNotFound.Error(), nil
+	case errors.Is(err, domain.ErrWebhookNotFound):
+		return http.StatusNotFound, domain.ErrWebhookNotFound.Error(), nil
+	case errors.Is(err, domain.ErrDeliveryNotFound):
+		return http.StatusNotFound, domain.ErrDeliveryNotFound.Error(), nil
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, domain.ErrNotFound.Error(), nil
+	case errors.Is(err, domain.ErrDuplicateName):
+		return http.StatusConflict, domain.ErrDuplicateName.Error(), nil
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, err.Error(), nil
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, domain.ErrPreconditionFailed.Error(), nil
+	case errors.As(err, &lockedErr):
+		return http.StatusLocked, domain.ErrLocked.Error(), lockedErr.Lock
+	case errors.Is(err, domain.ErrLocked):
+		return http.StatusLocked, domain.ErrLocked.Error(), nil
+	case errors.Is(err, domain.ErrBulkAborted):
+		return http.StatusFailedDependency, domain.ErrBulkAborted.Error(), nil
+	default:
+		return http.StatusInternalServerError, fallback, nil
+	}
+}
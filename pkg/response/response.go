@@ -10,16 +10,33 @@ type Response struct {
 	StatusCode int         `json:"statusCode"`
 	Message    string      `json:"message"`
 	Data       interface{} `json:"data,omitempty"`
+	Meta       interface{} `json:"meta,omitempty"`
 }
 
 func OK(c *gin.Context, message string, data interface{}) {
 	c.JSON(http.StatusOK, Response{StatusCode: http.StatusOK, Message: message, Data: data})
 }
 
+// OKWithMeta is OK with extra metadata, such as pagination, next to the data.
+func OKWithMeta(c *gin.Context, message string, data interface{}, meta interface{}) {
+	c.JSON(http.StatusOK, Response{StatusCode: http.StatusOK, Message: message, Data: data, Meta: meta})
+}
+
 func Created(c *gin.Context, message string, data interface{}) {
 	c.JSON(http.StatusCreated, Response{StatusCode: http.StatusCreated, Message: message, Data: data})
 }
 
+// MultiStatus reports a batch whose items did not all succeed; data holds the
+// status of each item.
+func MultiStatus(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusMultiStatus, Response{StatusCode: http.StatusMultiStatus, Message: message, Data: data})
+}
+
+// JSON writes the envelope with an arbitrary status code.
+func JSON(c *gin.Context, status int, message string, data interface{}) {
+	c.JSON(status, Response{StatusCode: status, Message: message, Data: data})
+}
+
 func BadRequest(c *gin.Context, message string, data interface{}) {
 	c.JSON(http.StatusBadRequest, Response{StatusCode: http.StatusBadRequest, Message: message, Data: data})
 }
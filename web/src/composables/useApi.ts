@@ -6,12 +6,14 @@ export interface ApiState<T> {
     data: Data<T>
     loading: Ref<boolean>
     error: Ref<string | null>
+    status: Ref<number | null>
+    etag: Ref<string | null>
 }
 
 export interface UseApiReturn<T> extends ApiState<T> {
     execute: () => Promise<T | null>
     post: (body: unknown) => Promise<T | null>
-    put: (body: unknown) => Promise<T | null>
+    put: (body: unknown, headers?: Record<string, string>) => Promise<T | null>
     delete: () => Promise<boolean>
 }
 
@@ -19,6 +21,8 @@ export function useApi<T>(url: string, immediate = true): UseApiReturn<T> {
     const data = ref<T | null>(null)
     const loading = ref(false)
     const error = ref<string | null>(null)
+    const status = ref<number | null>(null)
+    const etag = ref<string | null>(null)
 
     const extractErrorMessage = async (response: Response): Promise<string> => {
         try {
@@ -34,6 +38,7 @@ export function useApi<T>(url: string, immediate = true): UseApiReturn<T> {
         error.value = null
         try {
             const response = await fetch(url)
+            etag.value = response.headers.get("ETag")
             if (!response.ok) {
                 const errorMessage = await extractErrorMessage(response)
                 throw new Error(errorMessage)
@@ -73,15 +78,18 @@ export function useApi<T>(url: string, immediate = true): UseApiReturn<T> {
         }
     }
 
-    async function put(body: unknown): Promise<T | null> {
+    async function put(body: unknown, headers: Record<string, string> = {}): Promise<T | null> {
         loading.value = true
         error.value = null
+        status.value = null
         try {
             const response = await fetch(url, {
                 method: "PUT",
-                headers: { "Content-Type": "application/json" },
+                headers: { "Content-Type": "application/json", ...headers },
                 body: JSON.stringify(body),
             })
+            status.value = response.status
+            etag.value = response.headers.get("ETag")
             if (!response.ok) {
                 const errorMessage = await extractErrorMessage(response)
                 throw new Error(errorMessage)
@@ -120,5 +128,5 @@ export function useApi<T>(url: string, immediate = true): UseApiReturn<T> {
         execute()
     }
 
-    return { data, loading, error, execute, post, put, delete: del }
+    return { data, loading, error, status, etag, execute, post, put, delete: del }
 }
@@ -7,13 +7,17 @@ interface UseTasksReturn {
     tasks: Ref<Task[]>
     loading: Ref<boolean>
     error: Ref<string | null>
+    conflict: Ref<boolean>
     loadTasks: () => Promise<void>
-    createTask: (taskData: Omit<Task, "id" | "order_number">) => Promise<Task | null>
+    createTask: (taskData: Omit<Task, "id" | "order_number" | "status" | "completed_at" | "version">) => Promise<Task | null>
     updateTask: (id: number, taskData: Partial<Task>) => Promise<Task | null>
     deleteTask: (id: number) => Promise<void>
     reorderTasks: (newTasks: Task[]) => Promise<Task[] | null>
 }
 
+const CONFLICT_MESSAGE = "This task was changed by someone else while you were editing it. Reload it and apply your changes again."
+const ORDER_CONFLICT_MESSAGE = "The task list was changed by someone else. It has been reloaded; move the tasks again."
+
 interface DefaultResponseApi<T> {
     data: T | null
     message: string
@@ -28,14 +32,19 @@ export function useTasks(): UseTasksReturn {
     const tasks = ref<Task[]>([])
     const loading = ref(false)
     const error = ref<string | null>(null)
+    const conflict = ref(false)
 
     const apiGet = useApi<DefaultResponseApi<Task[]>>(baseUrl, false)
+    // ETag of the task list as last loaded or reordered, sent with reorders
+    // so they do not overwrite changes made by others in the meantime.
+    let listVersion: string | null = null
 
     async function loadTasks(): Promise<void> {
         loading.value = true;
         error.value = null;
         try {
             const response = await apiGet.execute();
+            listVersion = apiGet.etag.value
             tasks.value = response?.data ? [...response.data].sort((a, b) => a.order_number - b.order_number) : []
         } catch (err) {
             const errorMessage = err instanceof Error ? err.message : "Failed to load tasks";
@@ -50,7 +59,7 @@ export function useTasks(): UseTasksReturn {
         }
     }
 
-    async function createTask(taskData: Omit<Task, "id" | "order_number">): Promise<Task | null> {
+    async function createTask(taskData: Omit<Task, "id" | "order_number" | "status" | "completed_at" | "version">): Promise<Task | null> {
         loading.value = true
         error.value = null
         try {
@@ -87,14 +96,26 @@ export function useTasks(): UseTasksReturn {
         }
     }
 
+    // updateTask only overwrites the version of the task that was read, taken
+    // from taskData or else from the loaded tasks. If someone else saved it
+    // in the meantime, conflict is set and the tasks are reloaded.
     async function updateTask(id: number, taskData: Partial<Task>): Promise<Task | null> {
         loading.value = true
         error.value = null
+        conflict.value = false
         try {
+            const { version = tasks.value.find(t => t.id === id)?.version, ...body } = taskData
+            const headers: Record<string, string> = version ? { "If-Match": `"${version}"` } : {}
+
             const apiPut = useApi<DefaultResponseApi<Task>>(`${baseUrl}/${id}`, false)
-            const response = await apiPut.put(taskData)
+            const response = await apiPut.put(body, headers)
 
             if (!response) {
+                if (apiPut.status.value === 412) {
+                    conflict.value = true
+                    await loadTasks()
+                    throw new Error(CONFLICT_MESSAGE)
+                }
                 throw new Error(apiPut.error.value || "Failed to update task")
             }
 
@@ -162,16 +183,20 @@ export function useTasks(): UseTasksReturn {
         try {
             const sortedTasks = [...newTasks].sort((a, b) => a.order_number - b.order_number);
 
-            for (const task of sortedTasks) {
-                const apiPost = useApi<DefaultResponseApi<Task>>(`${baseUrl}/${task.id}/reorder`, false);
-                const response = await apiPost.post({ order: task.order_number });
+            const headers: Record<string, string> = listVersion ? { "If-Match": listVersion } : {}
+            const apiPut = useApi<DefaultResponseApi<Task[]>>(`${baseUrl}/order`, false);
+            const response = await apiPut.put({ ids: sortedTasks.map(t => t.id) }, headers);
 
-                if (!response) {
-                    throw new Error(apiPost.error.value || "Failed to reorder task");
+            if (!response) {
+                if (apiPut.status.value === 412) {
+                    await loadTasks()
+                    throw new Error(ORDER_CONFLICT_MESSAGE)
                 }
+                throw new Error(apiPut.error.value || "Failed to reorder tasks");
             }
 
-            await loadTasks()
+            listVersion = apiPut.etag.value
+            tasks.value = response.data ?? []
 
             addToast({
                 title: "Success",
@@ -198,6 +223,7 @@ export function useTasks(): UseTasksReturn {
         tasks,
         loading,
         error,
+        conflict,
         loadTasks,
         createTask,
         updateTask,
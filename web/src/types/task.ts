@@ -4,8 +4,13 @@
     cost: number
     deadline: string
     order_number: number
+    status: TaskStatus
+    completed_at: string | null
+    version: number
 }
 
+export type TaskStatus = "todo" | "in_progress" | "done" | "cancelled"
+
 export interface TaskFormData {
     name: string
     cost: string